@@ -0,0 +1,84 @@
+package ramix
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type BackpressurePolicy uint8
+
+const (
+	BackpressureClose BackpressurePolicy = iota
+	BackpressureBlock
+	BackpressureDrop
+	BackpressureDropOldest
+)
+
+func (p BackpressurePolicy) String() string {
+	switch p {
+	case BackpressureClose:
+		return "close"
+	case BackpressureBlock:
+		return "block"
+	case BackpressureDrop:
+		return "drop"
+	case BackpressureDropOldest:
+		return "drop-oldest"
+	default:
+		return fmt.Sprintf("BackpressurePolicy(%d)", p)
+	}
+}
+
+type Backpressure struct {
+	Policy    BackpressurePolicy
+	Timeout   time.Duration
+	BusyEvent uint32
+}
+
+func validateBackpressure(backpressure Backpressure) error {
+	switch backpressure.Policy {
+	case BackpressureClose, BackpressureDrop, BackpressureDropOldest:
+	case BackpressureBlock:
+		if backpressure.Timeout <= 0 {
+			return fmt.Errorf("%w: backpressure block timeout must be positive: %s", ErrInvalidConfiguration, backpressure.Timeout)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported backpressure policy %q", ErrInvalidConfiguration, backpressure.Policy.String())
+	}
+	return nil
+}
+
+func (g *routeGroup) SetBackpressure(backpressure Backpressure) error {
+	if err := validateBackpressure(backpressure); err != nil {
+		return err
+	}
+	if g.server != nil {
+		g.server.stateMu.Lock()
+		defer g.server.stateMu.Unlock()
+		if err := g.server.mutationErrorLocked(); err != nil {
+			return err
+		}
+	}
+	g.router.mu.Lock()
+	g.settings.backpressure = &backpressure
+	g.router.mu.Unlock()
+	return nil
+}
+
+func (s *Server) backpressureFor(settings routeSettings) Backpressure {
+	if settings.backpressure != nil {
+		return *settings.backpressure
+	}
+	return s.Backpressure
+}
+
+func (s *Server) replyBusy(connection Connection, event uint32) {
+	if sender, ok := connection.(interface{ trySend(uint32, []byte) error }); ok {
+		_ = sender.trySend(event, nil)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = connection.Send(ctx, event, nil)
+}
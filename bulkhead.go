@@ -0,0 +1,155 @@
+package ramix
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+type BulkheadConfig struct {
+	Name          string
+	MaxConcurrent int
+	MaxQueued     int
+	RejectEvent   uint32
+}
+
+type Bulkhead struct {
+	config BulkheadConfig
+
+	mu       sync.Mutex
+	active   int
+	waiting  []bulkheadWaiter
+	rejected atomic.Uint64
+}
+
+type bulkheadWaiter struct {
+	task   *Context
+	worker *worker
+}
+
+type BulkheadStats struct {
+	Name     string
+	Active   uint64
+	Queued   uint64
+	Rejected uint64
+}
+
+func NewBulkhead(config BulkheadConfig) (*Bulkhead, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("%w: bulkhead name must not be empty", ErrInvalidConfiguration)
+	}
+	if config.MaxConcurrent <= 0 {
+		return nil, fmt.Errorf("%w: bulkhead %q max concurrent must be positive: %d", ErrInvalidConfiguration, config.Name, config.MaxConcurrent)
+	}
+	if config.MaxQueued < 0 {
+		return nil, fmt.Errorf("%w: bulkhead %q max queued must not be negative: %d", ErrInvalidConfiguration, config.Name, config.MaxQueued)
+	}
+	return &Bulkhead{config: config}, nil
+}
+
+func (b *Bulkhead) Stats() BulkheadStats {
+	b.mu.Lock()
+	active, queued := b.active, len(b.waiting)
+	b.mu.Unlock()
+	return BulkheadStats{
+		Name:     b.config.Name,
+		Active:   uint64(active),
+		Queued:   uint64(queued),
+		Rejected: b.rejected.Load(),
+	}
+}
+
+func WithBulkhead(bulkhead *Bulkhead) RouteOption {
+	return func(settings *routeSettings) {
+		settings.bulkhead = bulkhead
+	}
+}
+
+func (g *routeGroup) SetBulkhead(bulkhead *Bulkhead) error {
+	if bulkhead == nil {
+		return fmt.Errorf("%w: bulkhead must not be nil", ErrInvalidConfiguration)
+	}
+	if g.server != nil {
+		g.server.stateMu.Lock()
+		defer g.server.stateMu.Unlock()
+		if err := g.server.mutationErrorLocked(); err != nil {
+			return err
+		}
+	}
+	g.router.mu.Lock()
+	g.settings.bulkhead = bulkhead
+	g.router.mu.Unlock()
+	return nil
+}
+
+func (b *Bulkhead) enter(task *Context, w *worker) (admitted, queued bool) {
+	b.mu.Lock()
+	switch {
+	case task.bulkheadSlot:
+		admitted = true
+	case b.active < b.config.MaxConcurrent:
+		b.active++
+		task.bulkheadSlot = true
+		admitted = true
+	case len(b.waiting) < b.config.MaxQueued:
+		b.waiting = append(b.waiting, bulkheadWaiter{task: task, worker: w})
+		queued = true
+	}
+	b.mu.Unlock()
+
+	if !admitted && !queued {
+		b.rejected.Add(1)
+		if b.config.RejectEvent != 0 {
+			_ = task.Connection.Send(task, b.config.RejectEvent, nil)
+		}
+	}
+	return admitted, queued
+}
+
+func (b *Bulkhead) leave(task *Context) {
+	b.mu.Lock()
+	task.bulkheadSlot = false
+	if len(b.waiting) == 0 {
+		b.active--
+		b.mu.Unlock()
+		return
+	}
+	next := b.waiting[0]
+	b.waiting[0] = bulkheadWaiter{}
+	b.waiting = b.waiting[1:]
+	next.task.bulkheadSlot = true
+	b.mu.Unlock()
+
+	next.worker.pool.requeue(next.worker, next.task)
+}
+
+func (r *router) bulkheadStats() []BulkheadStats {
+	r.mu.RLock()
+	bulkheads := append([]*Bulkhead(nil), r.bulkheads...)
+	r.mu.RUnlock()
+
+	stats := make([]BulkheadStats, 0, len(bulkheads))
+	for _, bulkhead := range bulkheads {
+		stats = append(stats, bulkhead.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+func (r *router) registerBulkheadLocked(bulkhead *Bulkhead) error {
+	for _, registered := range r.bulkheads {
+		if registered == bulkhead {
+			return nil
+		}
+		if registered.config.Name == bulkhead.config.Name {
+			return fmt.Errorf("%w: duplicate bulkhead name %q", ErrInvalidConfiguration, bulkhead.config.Name)
+		}
+	}
+	r.bulkheads = append(r.bulkheads, bulkhead)
+	return nil
+}
+
+func (s *Server) BulkheadStats() []BulkheadStats {
+	return s.router.bulkheadStats()
+}
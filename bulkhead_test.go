@@ -0,0 +1,330 @@
+package ramix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewBulkheadValidatesConfig(t *testing.T) {
+	for _, config := range []BulkheadConfig{
+		{MaxConcurrent: 1},
+		{Name: "history"},
+		{Name: "history", MaxConcurrent: 1, MaxQueued: -1},
+	} {
+		if _, err := NewBulkhead(config); !errors.Is(err, ErrInvalidConfiguration) {
+			t.Errorf("NewBulkhead(%+v) error = %v, want ErrInvalidConfiguration", config, err)
+		}
+	}
+}
+
+func newTestBulkhead(t *testing.T, config BulkheadConfig) *Bulkhead {
+	t.Helper()
+
+	bulkhead, err := NewBulkhead(config)
+	if err != nil {
+		t.Fatalf("NewBulkhead() error = %v", err)
+	}
+	return bulkhead
+}
+
+func TestRouteGroup_SetBulkhead(t *testing.T) {
+	history := newTestBulkhead(t, BulkheadConfig{Name: "history", MaxConcurrent: 1})
+	reports := newTestBulkhead(t, BulkheadConfig{Name: "reports", MaxConcurrent: 1})
+	rg := newGroup(newRouter())
+
+	if err := rg.RegisterRoute(1, func(*Context) {}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	if err := rg.SetBulkhead(nil); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Fatalf("SetBulkhead(nil) error = %v, want ErrInvalidConfiguration", err)
+	}
+	if err := rg.SetBulkhead(history); err != nil {
+		t.Fatalf("SetBulkhead() error = %v", err)
+	}
+	if err := rg.Group().RegisterRoute(2, func(*Context) {}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	if err := rg.RegisterRoute(3, func(*Context) {}, WithBulkhead(reports)); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	duplicate := newTestBulkhead(t, BulkheadConfig{Name: "history", MaxConcurrent: 2})
+	if err := rg.RegisterRoute(4, func(*Context) {}, WithBulkhead(duplicate)); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Fatalf("RegisterRoute() with duplicate bulkhead name error = %v, want ErrInvalidConfiguration", err)
+	}
+
+	settings := rg.router.freezeSettings()
+	if settings[1].bulkhead != nil || settings[2].bulkhead != history || settings[3].bulkhead != reports {
+		t.Error("Expected routes 2 and 3 to use the history and reports bulkheads, got", settings)
+	}
+	var names []string
+	for _, stats := range rg.router.bulkheadStats() {
+		names = append(names, stats.Name)
+	}
+	if want := []string{"history", "reports"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("bulkhead stats names = %v, want %v", names, want)
+	}
+}
+
+func TestWorkerPoolBulkheadQueuesWithoutBlockingWorkers(t *testing.T) {
+	pool := newWorkerPool(2, 4)
+	pool.start()
+	bulkhead := newTestBulkhead(t, BulkheadConfig{Name: "history", MaxConcurrent: 1, MaxQueued: 1, RejectEvent: 503})
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newContext(context.Background(), &testConnection{id: 0}, nil)
+	first.bulkhead = bulkhead
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	queuedRan := make(chan struct{})
+	queued := newContext(context.Background(), &testConnection{id: 3}, nil)
+	queued.bulkhead = bulkhead
+	queued.handlers = []Handler{func(*Context) { close(queuedRan) }}
+	otherRan := make(chan struct{})
+	other := newContext(context.Background(), &testConnection{id: 1}, nil)
+	other.handlers = []Handler{func(*Context) { close(otherRan) }}
+	rejectedConnection := &recordingConnection{}
+	rejected := newContext(context.Background(), rejectedConnection, nil)
+	rejected.bulkhead = bulkhead
+	rejected.handlers = []Handler{func(*Context) {
+		t.Error("rejected task handler should not run")
+	}}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+	if err := pool.submit(queued); err != nil {
+		t.Fatalf("submit(queued) error = %v", err)
+	}
+	if err := pool.submit(other); err != nil {
+		t.Fatalf("submit(other) error = %v", err)
+	}
+	waitForSignal(t, otherRan, "task on the worker of the queued task")
+	if err := pool.submit(rejected); err != nil {
+		t.Fatalf("submit(rejected) error = %v", err)
+	}
+	waitForTaskDone(t, rejected)
+
+	if got, want := bulkhead.Stats(), (BulkheadStats{Name: "history", Active: 1, Queued: 1, Rejected: 1}); got != want {
+		t.Fatalf("bulkhead stats = %+v, want %+v", got, want)
+	}
+	if got := rejectedConnection.messages(); len(got) != 1 || got[0].event != 503 {
+		t.Fatalf("rejected replies = %v, want one 503 reply", got)
+	}
+	assertNotClosed(t, queuedRan, "queued bulkhead task")
+
+	close(block)
+	waitForSignal(t, queuedRan, "queued bulkhead task")
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+	if got, want := bulkhead.Stats(), (BulkheadStats{Name: "history", Rejected: 1}); got != want {
+		t.Fatalf("bulkhead stats after drain = %+v, want %+v", got, want)
+	}
+}
+
+func TestWorkerPoolBulkheadKeepsConnectionOrder(t *testing.T) {
+	pool := newWorkerPool(2, 4)
+	pool.start()
+	bulkhead := newTestBulkhead(t, BulkheadConfig{Name: "history", MaxConcurrent: 1, MaxQueued: 1})
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newContext(context.Background(), &testConnection{id: 0}, nil)
+	first.bulkhead = bulkhead
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+
+	order := make(chan string, 3)
+	tasks := make([]*Context, 0, 3)
+	for _, name := range []string{"bulkhead", "plain", "bulkhead again"} {
+		name := name
+		task := newContext(context.Background(), &testConnection{id: 3}, nil)
+		if name != "plain" {
+			task.bulkhead = bulkhead
+		}
+		task.handlers = []Handler{func(*Context) { order <- name }}
+		if err := pool.submit(task); err != nil {
+			t.Fatalf("submit(%s) error = %v", name, err)
+		}
+		tasks = append(tasks, task)
+	}
+	otherRan := make(chan struct{})
+	other := newContext(context.Background(), &testConnection{id: 1}, nil)
+	other.handlers = []Handler{func(*Context) { close(otherRan) }}
+	if err := pool.submit(other); err != nil {
+		t.Fatalf("submit(other) error = %v", err)
+	}
+	waitForSignal(t, otherRan, "task of another connection")
+	select {
+	case name := <-order:
+		t.Fatalf("task %q ran before the bulkhead task ahead of it", name)
+	default:
+	}
+
+	close(block)
+	for _, task := range tasks {
+		waitForTaskDone(t, task)
+	}
+	close(order)
+	var got []string
+	for name := range order {
+		got = append(got, name)
+	}
+	if want := []string{"bulkhead", "plain", "bulkhead again"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("run order = %q, want %q", got, want)
+	}
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+}
+
+func TestWorkerPoolCountsParkedTasksAgainstCapacity(t *testing.T) {
+	pool := newWorkerPool(2, 2)
+	pool.start()
+	bulkhead := newTestBulkhead(t, BulkheadConfig{Name: "history", MaxConcurrent: 1, MaxQueued: 1})
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newContext(context.Background(), &testConnection{id: 0}, nil)
+	first.bulkhead = bulkhead
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+
+	order := make(chan string, 4)
+	newTask := func(name string) *Context {
+		task := newContext(context.Background(), &testConnection{id: 3}, nil)
+		task.handlers = []Handler{func(*Context) { order <- name }}
+		return task
+	}
+	worker := pool.workers[pool.workerIndex(&testConnection{id: 3})]
+	held := newTask("held")
+	held.bulkhead = bulkhead
+	parked := newTask("parked")
+	for i, task := range []*Context{held, parked, newTask("parked again")} {
+		if err := pool.submit(task); err != nil {
+			t.Fatalf("submit(%d) error = %v", i, err)
+		}
+		waitForParkedTasks(t, worker, i)
+	}
+
+	if err := pool.submit(newTask("rejected")); !errors.Is(err, ErrWorkerQueueFull) {
+		t.Fatalf("submit(rejected) error = %v, want %v", err, ErrWorkerQueueFull)
+	}
+	evicting := newTask("evicting")
+	evicting.backpressure = Backpressure{Policy: BackpressureDropOldest}
+	if err := pool.submit(evicting); err != nil {
+		t.Fatalf("submit(evicting) error = %v", err)
+	}
+	waitForTaskDone(t, parked)
+
+	close(block)
+	waitForTaskDone(t, evicting)
+	close(order)
+	var got []string
+	for name := range order {
+		got = append(got, name)
+	}
+	if want := []string{"held", "parked again", "evicting"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("run order = %q, want %q", got, want)
+	}
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+}
+
+func TestBulkheadRejectsWithoutReplyWhenRejectEventUnset(t *testing.T) {
+	bulkhead := newTestBulkhead(t, BulkheadConfig{Name: "history", MaxConcurrent: 1})
+	bulkhead.active = 1
+	connection := &recordingConnection{}
+	task := newContext(context.Background(), connection, nil)
+
+	if admitted, queued := bulkhead.enter(task, nil); admitted || queued {
+		t.Fatalf("enter() = (%t, %t), want (false, false)", admitted, queued)
+	}
+	if got := connection.messages(); len(got) != 0 {
+		t.Fatalf("rejected replies = %v, want none", got)
+	}
+	if got := bulkhead.Stats().Rejected; got != 1 {
+		t.Fatalf("Rejected = %d, want 1", got)
+	}
+}
+
+func waitForParkedTasks(t *testing.T, w *worker, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		w.mu.Lock()
+		parked, holds := w.parked, len(w.held)
+		w.mu.Unlock()
+		if parked == want && holds == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("parked tasks = %d with %d holds, want %d with 1 hold", parked, holds, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func waitForTaskDone(t *testing.T, task *Context) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		<-task.Done()
+		close(done)
+	}()
+	waitForSignal(t, done, "task finish")
+}
+
+func TestStatsHandlersExportBulkheads(t *testing.T) {
+	server := newStatsExportServer(t)
+	bulkhead := newTestBulkhead(t, BulkheadConfig{Name: "history", MaxConcurrent: 1})
+	if err := server.RegisterRoute(1, func(*Context) {}, WithBulkhead(bulkhead)); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	bulkhead.rejected.Add(2)
+
+	recorder := httptest.NewRecorder()
+	StatsJSONHandler(server).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	var body struct {
+		Bulkheads []map[string]any `json:"bulkheads"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v; body = %s", err, recorder.Body.String())
+	}
+	want := []map[string]any{{"name": "history", "active": 0.0, "queued": 0.0, "rejected": 2.0}}
+	if !reflect.DeepEqual(body.Bulkheads, want) {
+		t.Fatalf("bulkheads = %v, want %v", body.Bulkheads, want)
+	}
+
+	recorder = httptest.NewRecorder()
+	StatsPrometheusHandler(server).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	metrics := recorder.Body.String()
+	assertPrometheusContains(t, metrics, "# TYPE ramix_bulkhead_active gauge")
+	assertPrometheusContains(t, metrics, `ramix_bulkhead_queued{bulkhead="history"} 0`)
+	assertPrometheusContains(t, metrics, `ramix_bulkhead_rejected_total{bulkhead="history"} 2`)
+}
@@ -0,0 +1,181 @@
+package ramix
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type CertificateReloadErrorHandler func(error)
+
+type certificateReloader struct {
+	certFile    string
+	keyFile     string
+	certificate atomic.Pointer[tls.Certificate]
+
+	mu    sync.Mutex
+	stamp certificateFilesStamp
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type certificateFilesStamp struct {
+	certModTime time.Time
+	certSize    int64
+	keyModTime  time.Time
+	keySize     int64
+}
+
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	reloader := &certificateReloader{certFile: certFile, keyFile: keyFile, stop: make(chan struct{})}
+	reloader.stamp = reloader.currentStamp()
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	reloader.certificate.Store(&certificate)
+	return reloader, nil
+}
+
+func (r *certificateReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stamp = r.currentStamp()
+	certificate, err := loadReplacementCertificate(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.certificate.Store(certificate)
+	return nil
+}
+
+func loadReplacementCertificate(certFile, keyFile string) (*tls.Certificate, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reload certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("reload certificate: %w", err)
+	}
+	if now := time.Now(); now.After(leaf.NotAfter) {
+		return nil, fmt.Errorf("reload certificate: %s expired at %s", certFile, leaf.NotAfter.Format(time.RFC3339))
+	}
+	certificate.Leaf = leaf
+	return &certificate, nil
+}
+
+func (r *certificateReloader) currentStamp() certificateFilesStamp {
+	var stamp certificateFilesStamp
+	if info, err := os.Stat(r.certFile); err == nil {
+		stamp.certModTime, stamp.certSize = info.ModTime(), info.Size()
+	}
+	if info, err := os.Stat(r.keyFile); err == nil {
+		stamp.keyModTime, stamp.keySize = info.ModTime(), info.Size()
+	}
+	return stamp
+}
+
+func (r *certificateReloader) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.currentStamp() != r.stamp
+}
+
+func (r *certificateReloader) watch(interval time.Duration, report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				report(err)
+			}
+		}
+	}
+}
+
+func (r *certificateReloader) close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func (r *certificateReloader) getCertificate(
+	configured func(*tls.ClientHelloInfo) (*tls.Certificate, error),
+	certificates []tls.Certificate,
+) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if configured != nil {
+			certificate, err := configured(hello)
+			if certificate != nil || err != nil {
+				return certificate, err
+			}
+		}
+		current := r.certificate.Load()
+		if len(certificates) == 0 {
+			return current, nil
+		}
+		if hello.SupportsCertificate(current) == nil {
+			return current, nil
+		}
+		for i := range certificates {
+			if hello.SupportsCertificate(&certificates[i]) == nil {
+				return &certificates[i], nil
+			}
+		}
+		return current, nil
+	}
+}
+
+func (s *Server) ReloadCertificates() error {
+	if s.CertFile == "" {
+		return fmt.Errorf("%w: certificate reload requires a cert file", ErrInvalidConfiguration)
+	}
+	reloader := s.certificateReloader()
+	if reloader == nil {
+		return fmt.Errorf("%w: certificates are loaded when the server runs", ErrServerNotRunning)
+	}
+	return reloader.reload()
+}
+
+func (s *Server) certificateReloader() *certificateReloader {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.certificates
+}
+
+func (s *Server) OnCertificateReloadError(callback CertificateReloadErrorHandler) error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if err := s.mutationErrorLocked(); err != nil {
+		return err
+	}
+	s.certificateReloadError = callback
+	return nil
+}
+
+func (s *Server) reportCertificateReloadError(err error) {
+	callback := s.runtimeReloadError
+	if callback == nil {
+		callback = s.certificateReloadError
+	}
+	if callback == nil {
+		debug("Certificate reload error: %v", err)
+		return
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			debug("Certificate reload error handler panic: %v", recovered)
+		}
+	}()
+	callback(err)
+}
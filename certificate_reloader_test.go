@@ -0,0 +1,170 @@
+package ramix
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testKeyPairFiles struct {
+	certFile string
+	keyFile  string
+}
+
+func newTestKeyPairFiles(t *testing.T) testKeyPairFiles {
+	t.Helper()
+	dir := t.TempDir()
+	return testKeyPairFiles{certFile: filepath.Join(dir, "cert.pem"), keyFile: filepath.Join(dir, "key.pem")}
+}
+
+// write stores a self-signed certificate for commonName that expires at
+// notAfter, and moves the files' modification time forward so that watchers
+// observe the change even on coarse-grained filesystems.
+func (f testKeyPairFiles) write(t *testing.T, commonName string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notAfter.Add(-48 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+	f.writeRaw(t,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	)
+}
+
+func (f testKeyPairFiles) writeRaw(t *testing.T, certPEM, keyPEM []byte) {
+	t.Helper()
+	modTime := time.Now().Add(time.Second)
+	if info, err := os.Stat(f.certFile); err == nil && !info.ModTime().Before(modTime) {
+		modTime = info.ModTime().Add(time.Second)
+	}
+	for path, data := range map[string][]byte{f.certFile: certPEM, f.keyFile: keyPEM} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Chtimes(%s) error = %v", path, err)
+		}
+	}
+}
+
+func servedCommonName(t *testing.T, reloader *certificateReloader) string {
+	t.Helper()
+	certificate := reloader.certificate.Load()
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReloaderValidatesBeforeSwapping(t *testing.T) {
+	files := newTestKeyPairFiles(t)
+	files.write(t, "first", time.Now().Add(time.Hour))
+	reloader, err := newCertificateReloader(files.certFile, files.keyFile)
+	if err != nil {
+		t.Fatalf("newCertificateReloader() error = %v", err)
+	}
+
+	files.writeRaw(t, []byte("not a certificate"), []byte("not a key"))
+	if err := reloader.reload(); err == nil {
+		t.Fatal("reload(invalid) error = nil, want error")
+	}
+	files.write(t, "expired", time.Now().Add(-time.Hour))
+	if err := reloader.reload(); err == nil {
+		t.Fatal("reload(expired) error = nil, want error")
+	}
+	if got := servedCommonName(t, reloader); got != "first" {
+		t.Fatalf("served certificate = %q after failed reloads, want first", got)
+	}
+
+	files.write(t, "second", time.Now().Add(time.Hour))
+	if err := reloader.reload(); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	if got := servedCommonName(t, reloader); got != "second" {
+		t.Fatalf("served certificate = %q, want second", got)
+	}
+}
+
+func TestCertificateReloaderWatchesFiles(t *testing.T) {
+	files := newTestKeyPairFiles(t)
+	files.write(t, "first", time.Now().Add(time.Hour))
+	reloader, err := newCertificateReloader(files.certFile, files.keyFile)
+	if err != nil {
+		t.Fatalf("newCertificateReloader() error = %v", err)
+	}
+	failures := make(chan error, 8)
+	done := make(chan struct{})
+	go func() {
+		reloader.watch(5*time.Millisecond, func(err error) { failures <- err })
+		close(done)
+	}()
+	defer func() {
+		reloader.close()
+		<-done
+	}()
+
+	files.writeRaw(t, []byte("not a certificate"), []byte("not a key"))
+	select {
+	case <-failures:
+	case <-time.After(time.Second):
+		t.Fatal("watch did not report the failed reload")
+	}
+
+	files.write(t, "second", time.Now().Add(time.Hour))
+	deadline := time.Now().Add(time.Second)
+	for servedCommonName(t, reloader) != "second" {
+		if time.Now().After(deadline) {
+			t.Fatal("watch did not reload the changed files")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReloadCertificatesRequiresCertFile(t *testing.T) {
+	server, err := NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	if err := server.ReloadCertificates(); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Fatalf("ReloadCertificates() error = %v, want %v", err, ErrInvalidConfiguration)
+	}
+}
+
+func TestReloadCertificatesBeforeRun(t *testing.T) {
+	files := newTestKeyPairFiles(t)
+	files.write(t, "first", time.Now().Add(24*time.Hour))
+	server, err := NewServer(WithCertFile(files.certFile), WithPrivateKeyFile(files.keyFile))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	if err := server.ReloadCertificates(); !errors.Is(err, ErrServerNotRunning) {
+		t.Fatalf("ReloadCertificates() error = %v, want %v", err, ErrServerNotRunning)
+	}
+}
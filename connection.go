@@ -2,6 +2,7 @@ package ramix
 
 import (
 	"context"
+	"crypto/x509"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -14,6 +15,26 @@ type Connection interface {
 	Send(context.Context, uint32, []byte) error
 }
 
+type ConnectionInfo struct {
+	ID              uint64
+	Transport       Transport
+	RemoteAddress   net.Addr
+	Subprotocol     string
+	Compression     bool
+	RTT             time.Duration
+	InFlight        uint64
+	Attributes      map[string]any
+	PeerCertificate *x509.Certificate
+}
+
+func ConnectionInfoOf(connection Connection) (ConnectionInfo, bool) {
+	provider, ok := connection.(interface{ Info() ConnectionInfo })
+	if !ok {
+		return ConnectionInfo{}, false
+	}
+	return provider.Info(), true
+}
+
 type connectionState uint32
 
 const (
@@ -43,8 +64,19 @@ type netConnection struct {
 	metricTransport Transport
 	transport       connectionTransport
 	writeMessage    func([]byte) error
+	writeMessages   func([][]byte) error
+	maxBatchBytes   int
 	frameDecoder    *FrameDecoder
 	activity        *activityClock
+	pings           pingTracker
+	ping            func([]byte) error
+	remoteAddress   net.Addr
+	peerCertificate *x509.Certificate
+	release         func()
+	locals          sync.Map
+	attributes      map[string]any
+	subprotocol     string
+	compression     bool
 
 	state   atomic.Uint32
 	stateMu sync.Mutex
@@ -59,7 +91,7 @@ type netConnection struct {
 	sendMu         sync.Mutex
 	acceptingSends bool
 	sendWG         sync.WaitGroup
-	outgoing       chan []byte
+	outgoing       *outgoingQueue
 	drainWriter    chan struct{}
 	sendStopOnce   sync.Once
 
@@ -75,6 +107,9 @@ type netConnection struct {
 	transportCloseOnce sync.Once
 	finalizeOnce       sync.Once
 
+	inFlightSlots chan struct{}
+	inFlight      atomic.Int64
+
 	closeReasonMu sync.Mutex
 	closeOp       ConnectionOperation
 	closeErr      error
@@ -116,11 +151,14 @@ func newNetConnection(
 		forceCtx:        forceCtx,
 		forceCancel:     forceCancel,
 		acceptingSends:  true,
-		outgoing:        make(chan []byte, server.ConnectionWriteBufferSize),
+		outgoing:        newOutgoingQueue(int(server.ConnectionWriteBufferSize), server.OutgoingQueue.MaxBytes),
 		drainWriter:     make(chan struct{}),
 		writerDone:      make(chan struct{}),
 		done:            make(chan struct{}),
 	}
+	if server.MaxInFlightPerConnection > 0 {
+		connection.inFlightSlots = make(chan struct{}, server.MaxInFlightPerConnection)
+	}
 	connection.state.Store(uint32(connectionOpen))
 	connection.activity.refresh()
 
@@ -132,16 +170,87 @@ func (c *netConnection) ID() uint64 {
 }
 
 func (c *netConnection) RemoteAddress() net.Addr {
+	if c.remoteAddress != nil {
+		return c.remoteAddress
+	}
 	if c.transport == nil {
 		return nil
 	}
 	return c.transport.RemoteAddr()
 }
 
+func (c *netConnection) connectionLocal(key any, create func() any) any {
+	if value, ok := c.locals.Load(key); ok {
+		return value
+	}
+	value, _ := c.locals.LoadOrStore(key, create())
+	return value
+}
+
+func (c *netConnection) fail(operation ConnectionOperation, err error) {
+	if c.tryRequestClose(operation, err) {
+		c.server.reportConnectionError(c.self, operation, err)
+	}
+}
+
+func (c *netConnection) Info() ConnectionInfo {
+	return ConnectionInfo{
+		ID:              c.id,
+		Transport:       c.metricTransport,
+		RemoteAddress:   c.RemoteAddress(),
+		Subprotocol:     c.subprotocol,
+		Compression:     c.compression,
+		RTT:             c.pings.roundTripTime(),
+		InFlight:        uint64(c.inFlight.Load()),
+		Attributes:      copyAttributes(c.attributes),
+		PeerCertificate: c.peerCertificate,
+	}
+}
+
+func (c *netConnection) Attribute(key string) any {
+	return c.attributes[key]
+}
+
+func (c *netConnection) setAttributes(attributes map[string]any) {
+	c.attributes = copyAttributes(attributes)
+}
+
+func copyAttributes(attributes map[string]any) map[string]any {
+	if len(attributes) == 0 {
+		return nil
+	}
+	copied := make(map[string]any, len(attributes))
+	for key, value := range attributes {
+		copied[key] = value
+	}
+	return copied
+}
+
 func (c *netConnection) statsTransport() Transport {
 	return c.metricTransport
 }
 
+func (c *netConnection) acquireInFlight(stopping <-chan struct{}) error {
+	if c.inFlightSlots != nil {
+		select {
+		case c.inFlightSlots <- struct{}{}:
+		case <-c.readCtx.Done():
+			return ErrConnectionClosed
+		case <-stopping:
+			return ErrServerStopping
+		}
+	}
+	c.inFlight.Add(1)
+	return nil
+}
+
+func (c *netConnection) releaseInFlight() {
+	c.inFlight.Add(-1)
+	if c.inFlightSlots != nil {
+		<-c.inFlightSlots
+	}
+}
+
 func (c *netConnection) taskContext() context.Context {
 	return c.forceCtx
 }
@@ -150,7 +259,24 @@ func (c *netConnection) Send(ctx context.Context, event uint32, body []byte) err
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if request, ok := ctx.(*Context); ok && request.repliesSuppressed() {
+		return ErrRequestTimedOut
+	}
+	return c.send(event, body, func(data []byte) error {
+		return c.enqueueOutgoing(ctx, data)
+	})
+}
 
+func (c *netConnection) trySend(event uint32, body []byte) error {
+	return c.send(event, body, func(data []byte) error {
+		if queued, _ := c.outgoing.push(data); !queued {
+			return ErrOutgoingQueueFull
+		}
+		return nil
+	})
+}
+
+func (c *netConnection) send(event uint32, body []byte, enqueue func([]byte) error) error {
 	encodedMessage, err := c.server.encoder.Encode(Message{
 		Event:    event,
 		Body:     body,
@@ -169,16 +295,7 @@ func (c *netConnection) Send(ctx context.Context, event uint32, body []byte) err
 	c.sendMu.Unlock()
 	defer c.sendWG.Done()
 
-	select {
-	case c.outgoing <- encodedMessage:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-c.sendCtx.Done():
-		return ErrConnectionClosed
-	case <-c.forceCtx.Done():
-		return ErrConnectionClosed
-	}
+	return enqueue(encodedMessage)
 }
 
 func (c *netConnection) start(self managedConnection, reader func()) {
@@ -211,6 +328,8 @@ func (c *netConnection) start(self managedConnection, reader func()) {
 func (c *netConnection) runWriter() {
 	defer close(c.writerDone)
 
+	var batch [][]byte
+
 	for {
 		select {
 		case <-c.forceCtx.Done():
@@ -218,44 +337,94 @@ func (c *netConnection) runWriter() {
 		default:
 		}
 
+		if messages := c.outgoing.popAll(batch); len(messages) > 0 {
+			batch = messages
+			if !c.flushOutgoing(batch) {
+				return
+			}
+			continue
+		}
+
 		select {
 		case <-c.forceCtx.Done():
 			return
-		case data := <-c.outgoing:
-			if err := c.writeOutgoing(data); err != nil {
-				if c.tryRequestClose(OperationWrite, err) {
-					c.server.reportConnectionError(c.self, OperationWrite, err)
-				}
-				return
-			}
+		case <-c.outgoing.ready:
+			c.waitFlushDelay()
 		case <-c.drainWriter:
-			for {
-				select {
-				case data := <-c.outgoing:
-					if err := c.writeOutgoing(data); err != nil {
-						if c.tryRequestClose(OperationWrite, err) {
-							c.server.reportConnectionError(c.self, OperationWrite, err)
-						}
-						return
-					}
-				default:
-					return
-				}
+			if messages := c.outgoing.popAll(batch); len(messages) > 0 {
+				c.flushOutgoing(messages)
 			}
+			return
 		}
 	}
 }
 
-func (c *netConnection) writeOutgoing(data []byte) error {
-	if err := c.writeMessage(data); err != nil {
-		return err
+func (c *netConnection) waitFlushDelay() {
+	if c.server.WriteFlushDelay <= 0 {
+		return
 	}
-	if len(data) >= 8 {
-		c.server.metrics.messageSent(c.statsTransport(), uint64(len(data)-8))
+	timer := time.NewTimer(c.server.WriteFlushDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.forceCtx.Done():
+	case <-c.drainWriter:
+	}
+}
+
+func (c *netConnection) flushOutgoing(messages [][]byte) bool {
+	if err := c.writeOutgoing(messages); err != nil {
+		if c.tryRequestClose(OperationWrite, err) {
+			c.server.reportConnectionError(c.self, OperationWrite, err)
+		}
+		return false
+	}
+	return true
+}
+
+func (c *netConnection) writeOutgoing(messages [][]byte) error {
+	for len(messages) > 0 {
+		count := c.batchLength(messages)
+		batch := messages[:count]
+		messages = messages[count:]
+		if count == 1 {
+			if err := c.writeMessage(batch[0]); err != nil {
+				return err
+			}
+		} else if err := c.writeMessages(batch); err != nil {
+			return err
+		}
+		c.messagesWritten(batch...)
 	}
 	return nil
 }
 
+func (c *netConnection) batchLength(messages [][]byte) int {
+	if c.writeMessages == nil {
+		return 1
+	}
+	if c.maxBatchBytes <= 0 {
+		return len(messages)
+	}
+	size := len(messages[0])
+	count := 1
+	for count < len(messages) && size+len(messages[count]) <= c.maxBatchBytes {
+		size += len(messages[count])
+		count++
+	}
+	return count
+}
+
+func (c *netConnection) messagesWritten(messages ...[]byte) {
+	transport := c.statsTransport()
+	c.server.metrics.batchWritten(transport)
+	for _, data := range messages {
+		if len(data) >= 8 {
+			c.server.metrics.messageSent(transport, uint64(len(data)-8))
+		}
+	}
+}
+
 func (c *netConnection) quiesceReads() error {
 	c.stateMu.Lock()
 	if c.connectionState() == connectionOpen {
@@ -356,6 +525,9 @@ func (c *netConnection) supervise(openHookDone <-chan struct{}) {
 		if c.started.Load() {
 			c.server.metrics.connectionClosed(c.statsTransport())
 		}
+		if c.release != nil {
+			c.release()
+		}
 		if c.self != nil {
 			c.server.invokeCloseHook(c.self)
 		}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"net"
+	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -163,7 +165,7 @@ func TestConnectionMetricsIgnoreShortSuccessfulWrite(t *testing.T) {
 	transport := newFakeLifecycleTransport()
 	server, connection := newLifecycleTestConnection(t, transport, 1)
 
-	if err := connection.writeOutgoing([]byte("short")); err != nil {
+	if err := connection.writeOutgoing([][]byte{[]byte("short")}); err != nil {
 		t.Fatalf("writeOutgoing(short) error = %v", err)
 	}
 
@@ -479,3 +481,181 @@ func assertNoErrorResult(t *testing.T, result <-chan error, label string) {
 	case <-time.After(20 * time.Millisecond):
 	}
 }
+
+func TestConnectionAcquireInFlightWaitsForRelease(t *testing.T) {
+	transport := newFakeLifecycleTransport()
+	server, _ := newLifecycleTestConnection(t, transport, 1)
+	server.MaxInFlightPerConnection = 1
+	connection, err := newNetConnection(2, server, TransportTCP, transport, transport.Write)
+	if err != nil {
+		t.Fatalf("newNetConnection() error = %v", err)
+	}
+	stopping := make(chan struct{})
+
+	if err := connection.acquireInFlight(stopping); err != nil {
+		t.Fatalf("acquireInFlight() error = %v", err)
+	}
+	acquired := make(chan error, 1)
+	go func() { acquired <- connection.acquireInFlight(stopping) }()
+	select {
+	case err := <-acquired:
+		t.Fatalf("acquireInFlight() over the limit returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if got := connection.Info().InFlight; got != 1 {
+		t.Fatalf("Info().InFlight = %d, want 1", got)
+	}
+
+	connection.releaseInFlight()
+	if err := <-acquired; err != nil {
+		t.Fatalf("acquireInFlight() after release error = %v", err)
+	}
+
+	go func() { acquired <- connection.acquireInFlight(stopping) }()
+	close(stopping)
+	if err := <-acquired; !errors.Is(err, ErrServerStopping) {
+		t.Fatalf("acquireInFlight() while stopping error = %v, want %v", err, ErrServerStopping)
+	}
+	connection.readCancel()
+	if err := connection.acquireInFlight(make(chan struct{})); !errors.Is(err, ErrConnectionClosed) {
+		t.Fatalf("acquireInFlight() after close error = %v, want %v", err, ErrConnectionClosed)
+	}
+}
+
+func TestConnectionWriterCoalescesQueuedMessages(t *testing.T) {
+	writeGate := make(chan struct{})
+	transport := newFakeLifecycleTransport()
+	transport.writeGate = writeGate
+	transport.writeStarted = make(chan struct{})
+	server, connection := newLifecycleTestConnection(t, transport, 8)
+	var batchSizes []int
+	connection.writeMessages = func(messages [][]byte) error {
+		batchSizes = append(batchSizes, len(messages))
+		for _, data := range messages {
+			if err := transport.Write(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	startLifecycleTestConnection(server, connection, transport)
+
+	if err := connection.Send(context.Background(), 1, []byte("first")); err != nil {
+		t.Fatalf("Send(1) error = %v", err)
+	}
+	waitForSignal(t, transport.writeStarted, "first write start")
+	for event := uint32(2); event <= 5; event++ {
+		if err := connection.Send(context.Background(), event, []byte("body")); err != nil {
+			t.Fatalf("Send(%d) error = %v", event, err)
+		}
+	}
+	close(writeGate)
+	if err := connection.stopSendsAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopSendsAndDrain() error = %v", err)
+	}
+
+	if len(batchSizes) != 1 || batchSizes[0] != 4 {
+		t.Fatalf("batched write sizes = %v, want [4] after a single write", batchSizes)
+	}
+	if got, want := messageEvents(transport.writtenMessages(t)), []uint32{1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("written events = %v, want %v", got, want)
+	}
+	stats := server.Stats()
+	if stats.TCP.WriteBatches != 2 || stats.TCP.SentMessages != 5 {
+		t.Fatalf("TCP write batches and sent messages = (%d, %d), want (2, 5)", stats.TCP.WriteBatches, stats.TCP.SentMessages)
+	}
+
+	connection.requestClose(OperationRead, net.ErrClosed)
+	if err := connection.wait(context.Background()); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+}
+
+func TestConnectionWriterSplitsBatchesAtMaxBatchBytes(t *testing.T) {
+	writeGate := make(chan struct{})
+	transport := newFakeLifecycleTransport()
+	transport.writeGate = writeGate
+	transport.writeStarted = make(chan struct{})
+	server, connection := newLifecycleTestConnection(t, transport, 8)
+	var batchSizes []int
+	connection.writeMessages = func(messages [][]byte) error {
+		batchSizes = append(batchSizes, len(messages))
+		for _, data := range messages {
+			if err := transport.Write(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// Each small message is an 8 byte header and a 4 byte body, so two fit.
+	connection.maxBatchBytes = 24
+	startLifecycleTestConnection(server, connection, transport)
+
+	if err := connection.Send(context.Background(), 1, []byte("first")); err != nil {
+		t.Fatalf("Send(1) error = %v", err)
+	}
+	waitForSignal(t, transport.writeStarted, "first write start")
+	bodies := []string{"body", "body", strings.Repeat("x", 40), "body", "body"}
+	for i, body := range bodies {
+		if err := connection.Send(context.Background(), uint32(i+2), []byte(body)); err != nil {
+			t.Fatalf("Send(%d) error = %v", i+2, err)
+		}
+	}
+	close(writeGate)
+	if err := connection.stopSendsAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopSendsAndDrain() error = %v", err)
+	}
+
+	if want := []int{2, 2}; !reflect.DeepEqual(batchSizes, want) {
+		t.Fatalf("batched write sizes = %v, want %v", batchSizes, want)
+	}
+	if got, want := messageEvents(transport.writtenMessages(t)), []uint32{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("written events = %v, want %v", got, want)
+	}
+	if got := server.Stats().TCP.WriteBatches; got != 4 {
+		t.Fatalf("TCP.WriteBatches = %d, want 4", got)
+	}
+
+	connection.requestClose(OperationRead, net.ErrClosed)
+	if err := connection.wait(context.Background()); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+}
+
+func TestConnectionBusyReplySkippedWhenOutgoingQueueFull(t *testing.T) {
+	writeGate := make(chan struct{})
+	transport := newFakeLifecycleTransport()
+	transport.writeGate = writeGate
+	transport.writeStarted = make(chan struct{})
+	server, connection := newLifecycleTestConnection(t, transport, 1)
+	server.HeartbeatInterval = time.Hour
+	startLifecycleTestConnection(server, connection, transport)
+
+	if err := connection.Send(context.Background(), 1, []byte("first")); err != nil {
+		t.Fatalf("Send(1) error = %v", err)
+	}
+	waitForSignal(t, transport.writeStarted, "first write start")
+	if err := connection.Send(context.Background(), 2, []byte("queued")); err != nil {
+		t.Fatalf("Send(2) error = %v", err)
+	}
+
+	replied := make(chan struct{})
+	go func() {
+		server.replyBusy(connection, 503)
+		close(replied)
+	}()
+	waitForSignal(t, replied, "busy reply on a full outgoing queue")
+
+	close(writeGate)
+	if err := connection.stopSendsAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopSendsAndDrain() error = %v", err)
+	}
+	if got, want := messageEvents(transport.writtenMessages(t)), []uint32{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("written events = %v, want %v", got, want)
+	}
+
+	connection.requestClose(OperationRead, net.ErrClosed)
+	if err := connection.wait(context.Background()); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+}
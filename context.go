@@ -2,10 +2,14 @@ package ramix
 
 import (
 	"context"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const abortIndex = math.MaxInt / 2
+
 type Context struct {
 	context.Context
 	Connection Connection
@@ -20,6 +24,17 @@ type Context struct {
 
 	metrics         *serverMetrics
 	metricTransport Transport
+
+	backpressure     Backpressure
+	orderIndependent bool
+	priority         Priority
+	enqueued         time.Time
+	maxQueueWait     time.Duration
+	bulkhead         *Bulkhead
+	bulkheadSlot     bool
+	release          func()
+	timedOut         atomic.Bool
+	replyDeadline    atomic.Int64
 }
 
 func (c *Context) Next() {
@@ -30,6 +45,18 @@ func (c *Context) Next() {
 	}
 }
 
+func (c *Context) Abort() {
+	c.step = abortIndex
+}
+
+func (c *Context) IsAborted() bool {
+	return c.step >= abortIndex
+}
+
+func (c *Context) EnqueuedAt() time.Time {
+	return c.enqueued
+}
+
 func (c *Context) Set(key string, value any) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -57,6 +84,9 @@ func (c *Context) cancelTask() {
 func (c *Context) finish() {
 	c.finishMu.Do(func() {
 		c.cancelTask()
+		if c.release != nil {
+			c.release()
+		}
 	})
 }
 
@@ -74,6 +104,20 @@ func (c *Context) taskDequeued() {
 	c.metrics.taskDequeued(c.metricTransport)
 }
 
+func (c *Context) priorityQueued() {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.priorityQueued(c.priority)
+}
+
+func (c *Context) priorityDequeued() {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.priorityDequeued(c.priority)
+}
+
 func (c *Context) taskRejected() {
 	if c.metrics == nil {
 		return
@@ -81,6 +125,57 @@ func (c *Context) taskRejected() {
 	c.metrics.taskRejected(c.metricTransport)
 }
 
+func (c *Context) taskEvicted() {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.taskEvicted(c.metricTransport)
+}
+
+func (c *Context) taskBlocked() {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.taskBlocked(c.metricTransport)
+}
+
+func (c *Context) messageThrottled() {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.messageThrottled(c.metricTransport)
+}
+
+func (c *Context) taskExpired() {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.taskExpired(c.metricTransport)
+}
+
+func (c *Context) taskShed() {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.taskShed(c.metricTransport)
+}
+
+func (c *Context) requestTimedOut() {
+	c.timedOut.Store(true)
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.requestTimedOut(c.metricTransport)
+}
+
+func (c *Context) repliesSuppressed() bool {
+	if c.timedOut.Load() {
+		return true
+	}
+	deadline := c.replyDeadline.Load()
+	return deadline != 0 && time.Now().UnixNano() >= deadline
+}
+
 func (c *Context) requestCompleted(duration time.Duration) {
 	if c.metrics == nil {
 		return
@@ -41,6 +41,52 @@ func TestContext_Next(t *testing.T) {
 	}
 }
 
+func TestContext_Abort(t *testing.T) {
+	c := &Context{
+		step: -1,
+	}
+
+	c.handlers = []Handler{
+		func(c *Context) {
+			c.Abort()
+		},
+		func(c *Context) {
+			c.Set("foo", "bar")
+		},
+	}
+
+	c.Next()
+
+	if !c.IsAborted() {
+		t.Error("Expected context to be aborted")
+	}
+
+	if c.Get("foo") != nil {
+		t.Error("Expected nil, got", c.Get("foo"))
+	}
+}
+
+func TestContext_IsAbortedFalseAfterCompletedChain(t *testing.T) {
+	var abortedAfterNext bool
+	c := &Context{
+		step: -1,
+	}
+
+	c.handlers = []Handler{
+		func(c *Context) {
+			c.Next()
+			abortedAfterNext = c.IsAborted()
+		},
+		func(c *Context) {},
+	}
+
+	c.Next()
+
+	if abortedAfterNext || c.IsAborted() {
+		t.Error("Expected context not to be aborted after a completed chain")
+	}
+}
+
 func TestContext_Set(t *testing.T) {
 	c := &Context{}
 	c.Set("foo", "bar")
@@ -9,9 +9,19 @@ var (
 	ErrConnectionClosed     = errors.New("connection closed")
 	ErrWorkerQueueFull      = errors.New("worker queue full")
 	ErrServerRunning        = errors.New("server running")
+	ErrServerNotRunning     = errors.New("server not running")
 	ErrServerStopping       = errors.New("server stopping")
 	ErrServerStopped        = errors.New("server stopped")
 	ErrShutdownTimeout      = errors.New("shutdown timeout")
+	ErrProxyProtocol        = errors.New("invalid proxy protocol header")
+	ErrIPConnectionLimit    = errors.New("per-ip connection limit reached")
+	ErrAcceptRateLimit      = errors.New("accept rate limit exceeded")
+	ErrIPDenied             = errors.New("ip address denied")
+	ErrServerFull           = errors.New("server full")
+	ErrRateLimited          = errors.New("rate limit exceeded")
+	ErrOutgoingQueueFull    = errors.New("outgoing queue full")
+	ErrSlowConsumer         = errors.New("slow consumer")
+	ErrRequestTimedOut      = errors.New("request timed out")
 )
 
 type ConnectionOperation string
@@ -0,0 +1,152 @@
+package ramix
+
+type taskQueue interface {
+	len() int
+	front() *Context
+	pushBack(task *Context)
+	popFront() *Context
+	evict() *Context
+}
+
+type fairQueue struct {
+	quantum int
+	queues  map[uint64]*connectionQueue
+	active  []*connectionQueue
+	cursor  int
+	count   int
+}
+
+type connectionQueue struct {
+	id      uint64
+	tasks   taskDeque
+	deficit int
+}
+
+func newFairQueue(quantum uint32) *fairQueue {
+	return &fairQueue{
+		quantum: int(quantum),
+		queues:  make(map[uint64]*connectionQueue),
+	}
+}
+
+func (q *fairQueue) len() int {
+	return q.count
+}
+
+func (q *fairQueue) front() *Context {
+	var oldest *Context
+	for _, queue := range q.active {
+		if task := queue.tasks.front(); oldest == nil || task.enqueued.Before(oldest.enqueued) {
+			oldest = task
+		}
+	}
+	return oldest
+}
+
+func (q *fairQueue) pushBack(task *Context) {
+	var id uint64
+	if task.Connection != nil {
+		id = task.Connection.ID()
+	}
+	queue := q.queues[id]
+	if queue == nil {
+		queue = &connectionQueue{id: id, tasks: newTaskDeque(4)}
+		q.queues[id] = queue
+		q.active = append(q.active, queue)
+		if len(q.active) == 1 {
+			queue.deficit = q.quantum
+		}
+	}
+	queue.tasks.pushBack(task)
+	q.count++
+}
+
+func (q *fairQueue) popFront() *Context {
+	if q.count == 0 {
+		return nil
+	}
+	if q.quantum > 0 {
+		q.skipIdleRounds()
+	}
+	for {
+		queue := q.active[q.cursor]
+		cost := taskCost(queue.tasks.front())
+		if q.quantum == 0 || queue.deficit >= cost {
+			task := q.pop(q.cursor)
+			if q.quantum > 0 {
+				queue.deficit -= cost
+			} else if queue.tasks.len() > 0 {
+				q.advance()
+			}
+			return task
+		}
+		q.advance()
+	}
+}
+
+func (q *fairQueue) evict() *Context {
+	if q.count == 0 {
+		return nil
+	}
+	longest := 0
+	for i, queue := range q.active {
+		if queue.tasks.len() > q.active[longest].tasks.len() {
+			longest = i
+		}
+	}
+	return q.pop(longest)
+}
+
+func (q *fairQueue) pop(i int) *Context {
+	queue := q.active[i]
+	task := queue.tasks.popFront()
+	q.count--
+	if queue.tasks.len() > 0 {
+		return task
+	}
+
+	delete(q.queues, queue.id)
+	copy(q.active[i:], q.active[i+1:])
+	q.active[len(q.active)-1] = nil
+	q.active = q.active[:len(q.active)-1]
+	switch {
+	case i < q.cursor:
+		q.cursor--
+	case i == q.cursor && len(q.active) > 0:
+		q.cursor %= len(q.active)
+		q.active[q.cursor].deficit += q.quantum
+	}
+	if len(q.active) == 0 {
+		q.cursor = 0
+	}
+	return task
+}
+
+func (q *fairQueue) skipIdleRounds() {
+	rounds := 0
+	for _, queue := range q.active {
+		short := taskCost(queue.tasks.front()) - queue.deficit
+		if short <= 0 {
+			return
+		}
+		if needed := (short + q.quantum - 1) / q.quantum; rounds == 0 || needed < rounds {
+			rounds = needed
+		}
+	}
+	for _, queue := range q.active {
+		queue.deficit += (rounds - 1) * q.quantum
+	}
+}
+
+func (q *fairQueue) advance() {
+	q.cursor = (q.cursor + 1) % len(q.active)
+	q.active[q.cursor].deficit += q.quantum
+}
+
+func taskCost(task *Context) int {
+	cost := (&Decoder{}).headerSize()
+	if task.Request != nil {
+		cost += len(task.Request.Message.Body)
+	}
+	return cost
+}
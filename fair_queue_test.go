@@ -0,0 +1,173 @@
+package ramix
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newFairQueueTask(connectionID uint64, body string) *Context {
+	return newContext(context.Background(), &testConnection{id: connectionID}, newRequest(Message{Body: []byte(body)}))
+}
+
+func popFairQueueBodies(q *fairQueue) []string {
+	var bodies []string
+	for task := q.popFront(); task != nil; task = q.popFront() {
+		bodies = append(bodies, string(task.Request.Message.Body))
+	}
+	return bodies
+}
+
+func TestFairQueueServesConnectionsInTurn(t *testing.T) {
+	q := newFairQueue(0)
+	for _, task := range []*Context{
+		newFairQueueTask(1, "a1"),
+		newFairQueueTask(1, "a2"),
+		newFairQueueTask(1, "a3"),
+		newFairQueueTask(2, "b1"),
+		newFairQueueTask(3, "c1"),
+		newFairQueueTask(2, "b2"),
+	} {
+		q.pushBack(task)
+	}
+
+	want := []string{"a1", "b1", "c1", "a2", "b2", "a3"}
+	if got := popFairQueueBodies(q); !reflect.DeepEqual(got, want) {
+		t.Fatalf("pop order = %v, want %v", got, want)
+	}
+	if q.len() != 0 || len(q.queues) != 0 || len(q.active) != 0 {
+		t.Fatalf("queue not empty after popping every task: len = %d", q.len())
+	}
+}
+
+func TestFairQueueDeficitRoundRobinByFrameBytes(t *testing.T) {
+	// Each turn is worth two frames with a 2 byte body.
+	q := newFairQueue(20)
+	large := strings.Repeat("x", 32)
+	for _, task := range []*Context{
+		newFairQueueTask(1, large),
+		newFairQueueTask(1, large+"2"),
+		newFairQueueTask(2, "b1"),
+		newFairQueueTask(2, "b2"),
+		newFairQueueTask(2, "b3"),
+		newFairQueueTask(2, "b4"),
+	} {
+		q.pushBack(task)
+	}
+
+	// Connection 1 needs two turns for each 40 byte frame while connection 2
+	// runs two small frames per turn.
+	want := []string{"b1", "b2", large, "b3", "b4", large + "2"}
+	if got := popFairQueueBodies(q); !reflect.DeepEqual(got, want) {
+		t.Fatalf("pop order = %v, want %v", got, want)
+	}
+}
+
+func TestFairQueueChargesEmptyBodies(t *testing.T) {
+	q := newFairQueue(8)
+	var tasks []*Context
+	for i := 0; i < 5; i++ {
+		tasks = append(tasks, newFairQueueTask(1, ""))
+	}
+	tasks = append(tasks, newFairQueueTask(2, ""))
+	for i := 0; i < 3; i++ {
+		tasks = append(tasks, newFairQueueTask(1, ""))
+	}
+	for _, task := range tasks {
+		q.pushBack(task)
+	}
+
+	var order []uint64
+	for task := q.popFront(); task != nil; task = q.popFront() {
+		order = append(order, task.Connection.ID())
+	}
+	want := []uint64{1, 2, 1, 1, 1, 1, 1, 1, 1}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("pop order by connection = %v, want %v", order, want)
+	}
+}
+
+func TestFairQueueSmallQuantumSkipsIdleRounds(t *testing.T) {
+	q := newFairQueue(1)
+	large := strings.Repeat("x", 1<<20)
+	q.pushBack(newFairQueueTask(1, large))
+	q.pushBack(newFairQueueTask(2, large+"2"))
+
+	want := []string{large, large + "2"}
+	if got := popFairQueueBodies(q); !reflect.DeepEqual(got, want) {
+		t.Fatal("pop order of large frames is not in connection turn order")
+	}
+	if len(q.active) != 0 {
+		t.Fatalf("active sub-queues = %d, want 0", len(q.active))
+	}
+}
+
+func TestFairQueueEvictsFromLongestConnection(t *testing.T) {
+	q := newFairQueue(0)
+	for _, task := range []*Context{
+		newFairQueueTask(1, "a1"),
+		newFairQueueTask(2, "b1"),
+		newFairQueueTask(2, "b2"),
+	} {
+		q.pushBack(task)
+	}
+
+	if got := string(q.evict().Request.Message.Body); got != "b1" {
+		t.Fatalf("evict() = %s, want b1", got)
+	}
+	if got := string(q.front().Request.Message.Body); got != "a1" {
+		t.Fatalf("front() = %s, want a1", got)
+	}
+	want := []string{"a1", "b2"}
+	if got := popFairQueueBodies(q); !reflect.DeepEqual(got, want) {
+		t.Fatalf("pop order = %v, want %v", got, want)
+	}
+}
+
+func TestWorkerPoolFairSchedulingBoundsQuietConnectionLatency(t *testing.T) {
+	pool := newWorkerPool(1, 16)
+	for level := range pool.workers[0].ordered {
+		pool.workers[0].ordered[level] = newFairQueue(0)
+	}
+	pool.start()
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newContext(context.Background(), &testConnection{id: 1}, nil)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+
+	ran := make(chan string, 5)
+	for _, body := range []string{"noisy-1", "noisy-2", "noisy-3", "noisy-4", "quiet"} {
+		connectionID := uint64(1)
+		if body == "quiet" {
+			connectionID = 2
+		}
+		task := newFairQueueTask(connectionID, body)
+		task.handlers = []Handler{func(ctx *Context) { ran <- string(ctx.Request.Message.Body) }}
+		if err := pool.submit(task); err != nil {
+			t.Fatalf("submit(%s) error = %v", body, err)
+		}
+	}
+	close(block)
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+	close(ran)
+
+	var order []string
+	for body := range ran {
+		order = append(order, body)
+	}
+	want := []string{"noisy-1", "quiet", "noisy-2", "noisy-3", "noisy-4"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("run order = %v, want %v", order, want)
+	}
+}
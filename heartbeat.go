@@ -2,6 +2,8 @@ package ramix
 
 import (
 	"context"
+	"encoding/binary"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -27,6 +29,43 @@ func (c *activityClock) alive(timeout time.Duration) bool {
 	return lastActive.Add(timeout).After(c.now())
 }
 
+type pingTracker struct {
+	mu       sync.Mutex
+	sequence uint64
+	sentAt   time.Time
+	pending  bool
+	rtt      atomic.Int64
+}
+
+func (t *pingTracker) next(now time.Time) []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sequence++
+	t.sentAt = now
+	t.pending = true
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, t.sequence)
+	return payload
+}
+
+func (t *pingTracker) pong(payload []byte, now time.Time) bool {
+	if len(payload) != 8 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.pending || binary.BigEndian.Uint64(payload) != t.sequence {
+		return false
+	}
+	t.pending = false
+	t.rtt.Store(int64(now.Sub(t.sentAt)))
+	return true
+}
+
+func (t *pingTracker) roundTripTime() time.Duration {
+	return time.Duration(t.rtt.Load())
+}
+
 func (c *netConnection) refreshActivity() {
 	c.activity.refresh()
 }
@@ -38,6 +77,22 @@ func (c *netConnection) checkHeartbeat() {
 	c.requestCloseIfOpen(OperationHeartbeat, context.DeadlineExceeded)
 }
 
+func (c *netConnection) sendPing() {
+	if c.ping == nil || c.connectionState() != connectionOpen {
+		return
+	}
+	if err := c.ping(c.pings.next(c.activity.now())); err != nil {
+		if c.tryRequestClose(OperationWrite, err) {
+			c.server.reportConnectionError(c.self, OperationWrite, err)
+		}
+	}
+}
+
+func (c *netConnection) receivePong(payload []byte) {
+	c.refreshActivity()
+	c.pings.pong(payload, c.activity.now())
+}
+
 func (c *netConnection) runHeartbeat() {
 	ticker := time.NewTicker(c.server.HeartbeatInterval)
 	defer ticker.Stop()
@@ -50,6 +105,7 @@ func (c *netConnection) runHeartbeat() {
 			return
 		case <-ticker.C:
 			c.checkHeartbeat()
+			c.sendPing()
 		}
 	}
 }
@@ -103,3 +103,32 @@ func TestHeartbeatExpirationDoesNotCloseQuiescingConnection(t *testing.T) {
 		t.Fatalf("connection state = %v, want draining", got)
 	}
 }
+
+func TestPingTrackerMeasuresLatestPing(t *testing.T) {
+	var tracker pingTracker
+	now := time.Unix(100, 0)
+
+	first := tracker.next(now)
+	second := tracker.next(now.Add(time.Second))
+	if tracker.pong(first, now.Add(2*time.Second)) {
+		t.Fatal("pong for superseded ping was accepted")
+	}
+	if tracker.pong([]byte("unsolicited"), now.Add(2*time.Second)) {
+		t.Fatal("unsolicited pong was accepted")
+	}
+	if got := tracker.roundTripTime(); got != 0 {
+		t.Fatalf("roundTripTime() before matching pong = %s, want 0", got)
+	}
+	if !tracker.pong(second, now.Add(1250*time.Millisecond)) {
+		t.Fatal("pong for latest ping was rejected")
+	}
+	if got, want := tracker.roundTripTime(), 250*time.Millisecond; got != want {
+		t.Fatalf("roundTripTime() = %s, want %s", got, want)
+	}
+	if tracker.pong(second, now.Add(5*time.Second)) {
+		t.Fatal("duplicate pong was accepted")
+	}
+	if got, want := tracker.roundTripTime(), 250*time.Millisecond; got != want {
+		t.Fatalf("roundTripTime() after duplicate pong = %s, want %s", got, want)
+	}
+}
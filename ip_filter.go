@@ -0,0 +1,78 @@
+package ramix
+
+import "net"
+
+type IPFilter struct {
+	Allow []string
+	Deny  []string
+}
+
+type compiledIPFilter struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+func compileIPFilter(filter IPFilter) (*compiledIPFilter, error) {
+	allow, err := parseTrustedCIDRs(filter.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseTrustedCIDRs(filter.Deny)
+	if err != nil {
+		return nil, err
+	}
+	return &compiledIPFilter{allow: allow, deny: deny}, nil
+}
+
+func (f *compiledIPFilter) permits(ip net.IP) bool {
+	if f == nil {
+		return true
+	}
+	if ip == nil {
+		return len(f.allow) == 0
+	}
+	if containsIP(f.deny, ip) {
+		return false
+	}
+	return len(f.allow) == 0 || containsIP(f.allow, ip)
+}
+
+func (f IPFilter) clone() IPFilter {
+	return IPFilter{
+		Allow: append([]string(nil), f.Allow...),
+		Deny:  append([]string(nil), f.Deny...),
+	}
+}
+
+func (s *Server) SetIPFilter(filter IPFilter, evict bool) (int, error) {
+	compiled, err := compileIPFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	s.ipFilter.Store(compiled)
+	if !evict {
+		return 0, nil
+	}
+	s.stateMu.Lock()
+	serving := s.state == stateRunning || s.state == stateStopping
+	manager := s.connectionManager
+	s.stateMu.Unlock()
+	if !serving {
+		return 0, nil
+	}
+	evicted := 0
+	for _, connection := range manager.snapshot() {
+		if compiled.permits(addressIP(connection.RemoteAddress())) {
+			continue
+		}
+		connection.requestClose(OperationProtocol, ErrIPDenied)
+		evicted++
+	}
+	return evicted, nil
+}
+
+func (s *Server) recheckIPFilter(connection managedConnection) {
+	if !s.ipFilter.Load().permits(addressIP(connection.RemoteAddress())) {
+		connection.requestClose(OperationProtocol, ErrIPDenied)
+	}
+}
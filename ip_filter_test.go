@@ -0,0 +1,79 @@
+package ramix
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestIPFilterPermits(t *testing.T) {
+	filter, err := compileIPFilter(IPFilter{
+		Allow: []string{"10.0.0.0/8", "2001:db8::/32"},
+		Deny:  []string{"10.0.0.13"},
+	})
+	if err != nil {
+		t.Fatalf("compileIPFilter() error = %v", err)
+	}
+	for _, test := range []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "10.1.2.3", want: true},
+		{ip: "10.0.0.13"},
+		{ip: "192.0.2.1"},
+		{ip: "2001:db8::1", want: true},
+	} {
+		if got := filter.permits(net.ParseIP(test.ip)); got != test.want {
+			t.Fatalf("permits(%s) = %v, want %v", test.ip, got, test.want)
+		}
+	}
+
+	denyOnly, err := compileIPFilter(IPFilter{Deny: []string{"192.0.2.0/24"}})
+	if err != nil {
+		t.Fatalf("compileIPFilter() error = %v", err)
+	}
+	if denyOnly.permits(net.ParseIP("192.0.2.1")) || !denyOnly.permits(net.ParseIP("198.51.100.1")) {
+		t.Fatal("deny-only filter did not allow every address outside the deny list")
+	}
+	if filter.permits(nil) || !denyOnly.permits(nil) {
+		t.Fatal("unknown address was not denied by the allow list only")
+	}
+}
+
+func TestIPFilterRecheckedWhenConnectionRegisters(t *testing.T) {
+	server, err := NewServer(WithIPFilter(IPFilter{Deny: []string{"192.0.2.1"}}))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	admitted := newManagedConnectionStub(1)
+	server.recheckIPFilter(admitted)
+	if got := admitted.closeCount.Load(); got != 0 {
+		t.Fatalf("close count under a deny-only filter = %d, want 0", got)
+	}
+
+	if _, err := server.SetIPFilter(IPFilter{Allow: []string{"10.0.0.0/8"}}, false); err != nil {
+		t.Fatalf("SetIPFilter() error = %v", err)
+	}
+	// The stub has no remote address, which an allow list must not admit.
+	denied := newManagedConnectionStub(2)
+	server.recheckIPFilter(denied)
+	if got := denied.closeCount.Load(); got != 1 {
+		t.Fatalf("close count under an allow list = %d, want 1", got)
+	}
+}
+
+func TestSetIPFilterRejectsInvalidFilter(t *testing.T) {
+	server, err := NewServer(WithIPFilter(IPFilter{Deny: []string{"192.0.2.1"}}))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	if _, err := server.SetIPFilter(IPFilter{Allow: []string{"not-an-ip"}}, false); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Fatalf("SetIPFilter() error = %v, want %v", err, ErrInvalidConfiguration)
+	}
+	if server.ipFilter.Load().permits(net.ParseIP("192.0.2.1")) {
+		t.Fatal("invalid SetIPFilter() replaced the current filter")
+	}
+	if evicted, err := server.SetIPFilter(IPFilter{}, true); err != nil || evicted != 0 {
+		t.Fatalf("SetIPFilter(before Run) = (%d, %v), want (0, nil)", evicted, err)
+	}
+}
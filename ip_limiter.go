@@ -0,0 +1,150 @@
+package ramix
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+const ipLimitSweepInterval = time.Minute
+
+type ipLimiter struct {
+	maxPerIP int
+	cidrs    []cidrLimit
+	rate     float64
+	burst    float64
+	now      func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*ipClient
+	lastSweep time.Time
+}
+
+type cidrLimit struct {
+	network *net.IPNet
+	max     int
+	active  int
+}
+
+type ipClient struct {
+	active int
+	bucket tokenBucket
+}
+
+func newIPLimiter(opts ServerOptions) (*ipLimiter, error) {
+	if opts.MaxConnectionsPerIP == 0 && len(opts.CIDRConnectionLimits) == 0 && opts.AcceptRatePerIP == 0 {
+		return nil, nil
+	}
+	limiter := &ipLimiter{
+		maxPerIP: opts.MaxConnectionsPerIP,
+		rate:     opts.AcceptRatePerIP,
+		burst:    float64(opts.AcceptBurstPerIP),
+		now:      time.Now,
+		clients:  make(map[string]*ipClient),
+	}
+	for cidr, max := range opts.CIDRConnectionLimits {
+		networks, err := parseTrustedCIDRs([]string{cidr})
+		if err != nil {
+			return nil, err
+		}
+		limiter.cidrs = append(limiter.cidrs, cidrLimit{network: networks[0], max: max})
+	}
+	return limiter, nil
+}
+
+func ipKey(ip net.IP) string {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return ipv4.String()
+	}
+	return ip.String()
+}
+
+func (l *ipLimiter) admit(ip net.IP) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	key := ipKey(ip)
+	client := l.clients[key]
+	if client == nil {
+		client = &ipClient{bucket: newTokenBucket(l.burst, now)}
+		l.clients[key] = client
+	}
+	if l.rate > 0 && !client.bucket.take(now, l.rate, l.burst) {
+		return ErrAcceptRateLimit
+	}
+	if l.maxPerIP > 0 && client.active >= l.maxPerIP {
+		return ErrIPConnectionLimit
+	}
+	for i := range l.cidrs {
+		if l.cidrs[i].network.Contains(ip) && l.cidrs[i].active >= l.cidrs[i].max {
+			return ErrIPConnectionLimit
+		}
+	}
+	client.active++
+	for i := range l.cidrs {
+		if l.cidrs[i].network.Contains(ip) {
+			l.cidrs[i].active++
+		}
+	}
+	return nil
+}
+
+func (l *ipLimiter) release(ip net.IP) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := ipKey(ip)
+	client := l.clients[key]
+	if client == nil || client.active == 0 {
+		return
+	}
+	client.active--
+	for i := range l.cidrs {
+		if l.cidrs[i].network.Contains(ip) && l.cidrs[i].active > 0 {
+			l.cidrs[i].active--
+		}
+	}
+	if client.active == 0 && l.rate == 0 {
+		delete(l.clients, key)
+	}
+}
+
+func (l *ipLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < ipLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, client := range l.clients {
+		if client.active > 0 {
+			continue
+		}
+		if client.bucket.full(now, l.rate, l.burst) {
+			delete(l.clients, key)
+		}
+	}
+}
+
+func (s *Server) admitClient(transport Transport, address net.Addr) (func(), error) {
+	ip := addressIP(address)
+	if !s.ipFilter.Load().permits(ip) {
+		s.metrics.ipDenied(transport)
+		s.reportConnectionRejected(transport, address, ErrIPDenied)
+		return nil, ErrIPDenied
+	}
+	if s.ipLimiter == nil || ip == nil {
+		return func() {}, nil
+	}
+	if err := s.ipLimiter.admit(ip); err != nil {
+		switch err {
+		case ErrAcceptRateLimit:
+			s.metrics.acceptRateRejected(transport)
+		default:
+			s.metrics.ipConnectionsRejected(transport)
+		}
+		s.reportConnectionRejected(transport, address, err)
+		return nil, err
+	}
+	var once sync.Once
+	return func() { once.Do(func() { s.ipLimiter.release(ip) }) }, nil
+}
@@ -0,0 +1,116 @@
+package ramix
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestIPLimiter(t *testing.T, options ...ServerOption) (*ipLimiter, *time.Time) {
+	t.Helper()
+	opts := defaultServerOptions()
+	for _, option := range options {
+		option(&opts)
+	}
+	limiter, err := newIPLimiter(opts)
+	if err != nil {
+		t.Fatalf("newIPLimiter() error = %v", err)
+	}
+	now := time.Unix(1000, 0)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestIPLimiterDisabledWithoutLimits(t *testing.T) {
+	limiter, err := newIPLimiter(defaultServerOptions())
+	if err != nil || limiter != nil {
+		t.Fatalf("newIPLimiter() = (%v, %v), want (nil, nil)", limiter, err)
+	}
+}
+
+func TestIPLimiterCapsConcurrentConnectionsPerIP(t *testing.T) {
+	limiter, _ := newTestIPLimiter(t, WithMaxConnectionsPerIP(2))
+	first := net.ParseIP("192.0.2.1")
+	for i := 0; i < 2; i++ {
+		if err := limiter.admit(first); err != nil {
+			t.Fatalf("admit(%d) error = %v", i, err)
+		}
+	}
+	if err := limiter.admit(net.ParseIP("::ffff:192.0.2.1")); !errors.Is(err, ErrIPConnectionLimit) {
+		t.Fatalf("admit(mapped address) error = %v, want %v", err, ErrIPConnectionLimit)
+	}
+	if err := limiter.admit(net.ParseIP("192.0.2.2")); err != nil {
+		t.Fatalf("admit(other ip) error = %v", err)
+	}
+
+	limiter.release(first)
+	if err := limiter.admit(first); err != nil {
+		t.Fatalf("admit(after release) error = %v", err)
+	}
+}
+
+func TestIPLimiterCapsConnectionsPerCIDR(t *testing.T) {
+	limiter, _ := newTestIPLimiter(t, WithCIDRConnectionLimit("198.51.100.0/24", 2))
+	for _, ip := range []string{"198.51.100.1", "198.51.100.2"} {
+		if err := limiter.admit(net.ParseIP(ip)); err != nil {
+			t.Fatalf("admit(%s) error = %v", ip, err)
+		}
+	}
+	if err := limiter.admit(net.ParseIP("198.51.100.3")); !errors.Is(err, ErrIPConnectionLimit) {
+		t.Fatalf("admit(third ip in range) error = %v, want %v", err, ErrIPConnectionLimit)
+	}
+	if err := limiter.admit(net.ParseIP("203.0.113.1")); err != nil {
+		t.Fatalf("admit(outside range) error = %v", err)
+	}
+	limiter.release(net.ParseIP("198.51.100.1"))
+	if err := limiter.admit(net.ParseIP("198.51.100.3")); err != nil {
+		t.Fatalf("admit(after release) error = %v", err)
+	}
+}
+
+func TestIPLimiterRateLimitsAccepts(t *testing.T) {
+	limiter, now := newTestIPLimiter(t, WithAcceptRateLimit(2, 3))
+	ip := net.ParseIP("2001:db8::1")
+	for i := 0; i < 3; i++ {
+		if err := limiter.admit(ip); err != nil {
+			t.Fatalf("admit(%d) error = %v", i, err)
+		}
+		limiter.release(ip)
+	}
+	if err := limiter.admit(ip); !errors.Is(err, ErrAcceptRateLimit) {
+		t.Fatalf("admit(burst exhausted) error = %v, want %v", err, ErrAcceptRateLimit)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if err := limiter.admit(ip); err != nil {
+		t.Fatalf("admit(after refill) error = %v", err)
+	}
+	if err := limiter.admit(ip); !errors.Is(err, ErrAcceptRateLimit) {
+		t.Fatalf("admit(refill exhausted) error = %v, want %v", err, ErrAcceptRateLimit)
+	}
+}
+
+func TestIPLimiterSweepsIdleClients(t *testing.T) {
+	limiter, now := newTestIPLimiter(t, WithAcceptRateLimit(1, 1))
+	idle := net.ParseIP("192.0.2.1")
+	connected := net.ParseIP("192.0.2.2")
+	if err := limiter.admit(idle); err != nil {
+		t.Fatalf("admit(idle) error = %v", err)
+	}
+	limiter.release(idle)
+	if err := limiter.admit(connected); err != nil {
+		t.Fatalf("admit(connected) error = %v", err)
+	}
+
+	*now = now.Add(ipLimitSweepInterval)
+	if err := limiter.admit(net.ParseIP("192.0.2.3")); err != nil {
+		t.Fatalf("admit(new) error = %v", err)
+	}
+	if _, ok := limiter.clients[ipKey(idle)]; ok {
+		t.Fatal("idle client with a full bucket was not swept")
+	}
+	if _, ok := limiter.clients[ipKey(connected)]; !ok {
+		t.Fatal("connected client was swept")
+	}
+}
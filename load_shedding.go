@@ -0,0 +1,84 @@
+package ramix
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+func WithRouteMaxQueueWait(maxQueueWait time.Duration) RouteOption {
+	return func(settings *routeSettings) {
+		settings.maxQueueWait = &maxQueueWait
+	}
+}
+
+func validateLoadShedding(opts ServerOptions) error {
+	if opts.MaxQueueWait < 0 {
+		return fmt.Errorf("%w: max queue wait must not be negative: %s", ErrInvalidConfiguration, opts.MaxQueueWait)
+	}
+	if opts.LoadSheddingTarget == 0 && opts.LoadSheddingInterval == 0 {
+		return nil
+	}
+	if opts.LoadSheddingTarget <= 0 {
+		return fmt.Errorf("%w: load shedding target must be positive: %s", ErrInvalidConfiguration, opts.LoadSheddingTarget)
+	}
+	if opts.LoadSheddingInterval < opts.LoadSheddingTarget {
+		return fmt.Errorf("%w: load shedding interval must be at least the target: target=%s interval=%s", ErrInvalidConfiguration, opts.LoadSheddingTarget, opts.LoadSheddingInterval)
+	}
+	return nil
+}
+
+type queueShedder struct {
+	target   time.Duration
+	interval time.Duration
+
+	firstAbove time.Time
+	dropNext   time.Time
+	count      int
+	dropping   bool
+}
+
+func (s *queueShedder) enabled() bool {
+	return s.target > 0
+}
+
+func (s *queueShedder) shed(now time.Time, wait time.Duration, empty bool) bool {
+	okToDrop := false
+	switch {
+	case wait < s.target || empty:
+		s.firstAbove = time.Time{}
+	case s.firstAbove.IsZero():
+		s.firstAbove = now.Add(s.interval)
+	case !now.Before(s.firstAbove):
+		okToDrop = true
+	}
+
+	if s.dropping {
+		if !okToDrop {
+			s.dropping = false
+			return false
+		}
+		if now.Before(s.dropNext) {
+			return false
+		}
+		s.count++
+		s.dropNext = s.controlLaw(s.dropNext)
+		return true
+	}
+	if !okToDrop {
+		return false
+	}
+
+	s.dropping = true
+	if s.count > 2 && now.Sub(s.dropNext) < 8*s.interval {
+		s.count -= 2
+	} else {
+		s.count = 1
+	}
+	s.dropNext = s.controlLaw(now)
+	return true
+}
+
+func (s *queueShedder) controlLaw(t time.Time) time.Time {
+	return t.Add(time.Duration(float64(s.interval) / math.Sqrt(float64(s.count))))
+}
@@ -0,0 +1,121 @@
+package ramix
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRouteGroup_RegisterRouteWithMaxQueueWait(t *testing.T) {
+	rg := newGroup(newRouter())
+
+	if err := rg.RegisterRoute(1, func(*Context) {}, WithRouteMaxQueueWait(time.Second)); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	if err := rg.RegisterRoute(2, func(*Context) {}, WithRouteMaxQueueWait(-time.Second)); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Fatalf("RegisterRoute() with negative wait error = %v, want ErrInvalidConfiguration", err)
+	}
+
+	settings := rg.router.freezeSettings()
+	if got := settings[1].maxQueueWait; got == nil || *got != time.Second {
+		t.Error("Expected route 1 to wait at most one second, got", got)
+	}
+}
+
+func TestWorkerPoolDropsTaskPastMaxQueueWait(t *testing.T) {
+	pool := newWorkerPool(1, 2)
+	pool.start()
+
+	var metrics serverMetrics
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newMetricsContext(&metrics, TransportTCP, 1)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	expired := newMetricsContext(&metrics, TransportTCP, 1)
+	expired.maxQueueWait = time.Nanosecond
+	expired.handlers = []Handler{func(*Context) {
+		t.Error("expired task handler should not run")
+	}}
+	ran := make(chan struct{})
+	patient := newMetricsContext(&metrics, TransportTCP, 1)
+	patient.handlers = []Handler{func(*Context) { close(ran) }}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+	if err := pool.submit(expired); err != nil {
+		t.Fatalf("submit(expired) error = %v", err)
+	}
+	if err := pool.submit(patient); err != nil {
+		t.Fatalf("submit(patient) error = %v", err)
+	}
+	close(block)
+	waitForSignal(t, ran, "task without max queue wait")
+
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+	stats := metrics.snapshot().TCP
+	if stats.ExpiredTasks != 1 || stats.CompletedRequests != 2 || stats.QueuedTasks != 0 {
+		t.Fatalf("expired, completed, queued tasks = %d, %d, %d, want 1, 2, 0", stats.ExpiredTasks, stats.CompletedRequests, stats.QueuedTasks)
+	}
+}
+
+func TestQueueShedderDropsAfterIntervalAboveTarget(t *testing.T) {
+	shedder := queueShedder{target: 5 * time.Millisecond, interval: 100 * time.Millisecond}
+	start := time.Now()
+	at := func(offset time.Duration) time.Time { return start.Add(offset) }
+
+	if shedder.shed(at(0), time.Millisecond, false) {
+		t.Fatal("shed a task below target")
+	}
+	if shedder.shed(at(10*time.Millisecond), 20*time.Millisecond, false) {
+		t.Fatal("shed the first task above target")
+	}
+	if shedder.shed(at(50*time.Millisecond), 20*time.Millisecond, false) {
+		t.Fatal("shed a task before the interval elapsed")
+	}
+	if !shedder.shed(at(110*time.Millisecond), 20*time.Millisecond, false) {
+		t.Fatal("kept a task after a whole interval above target")
+	}
+	if shedder.shed(at(120*time.Millisecond), 20*time.Millisecond, false) {
+		t.Fatal("shed a task before the next drop time")
+	}
+	// Drops follow each other after interval/sqrt(count): 100ms, then 70.7ms.
+	if !shedder.shed(at(210*time.Millisecond), 20*time.Millisecond, false) {
+		t.Fatal("kept a task at the second drop time")
+	}
+	if shedder.shed(at(270*time.Millisecond), 20*time.Millisecond, false) {
+		t.Fatal("shed a task before the third drop time")
+	}
+	if !shedder.shed(at(281*time.Millisecond), 20*time.Millisecond, false) {
+		t.Fatal("kept a task at the third drop time")
+	}
+	if shedder.count != 3 {
+		t.Fatalf("drop count = %d, want 3", shedder.count)
+	}
+
+	if shedder.shed(at(400*time.Millisecond), time.Millisecond, false) {
+		t.Fatal("shed a task below target")
+	}
+	if shedder.dropping {
+		t.Fatal("still dropping after the queue wait fell below target")
+	}
+}
+
+func TestQueueShedderKeepsTasksWhenQueueEmpties(t *testing.T) {
+	shedder := queueShedder{target: 5 * time.Millisecond, interval: 10 * time.Millisecond}
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		now := start.Add(time.Duration(i) * 20 * time.Millisecond)
+		if shedder.shed(now, time.Second, true) {
+			t.Fatalf("shed task %d although the queue emptied", i)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package ramix
 
 import (
+	"crypto/tls"
 	"fmt"
+	"math"
+	"net/http"
 	"net/url"
 	pathpkg "path"
 	"runtime"
@@ -35,6 +38,9 @@ type ServerOptions struct {
 	Port                      int
 	WebSocketPort             int
 	WebSocketPath             string
+	WebSocketAllowedOrigins   []string
+	WebSocketCheckOrigin      func(*http.Request) bool
+	WebSocketSubprotocols     []string
 	CertFile                  string
 	PrivateKeyFile            string
 	MaxConnectionsCount       int
@@ -47,6 +53,66 @@ type ServerOptions struct {
 	MaxFrameLength            uint64
 	HeartbeatInterval         time.Duration
 	HeartbeatTimeout          time.Duration
+	WebSocketPing             bool
+	TCPPing                   bool
+	TCPPingEvent              uint32
+
+	WebSocketCompression          bool
+	WebSocketCompressionLevel     int
+	WebSocketCompressionThreshold int
+
+	WebSocketTextSubprotocol string
+
+	ProxyProtocol              bool
+	ProxyProtocolTrustedCIDRs  []string
+	ProxyProtocolHeaderTimeout time.Duration
+
+	WebSocketTrustedProxies []string
+
+	TLSConfig           *tls.Config
+	TLSTransports       []Transport
+	TLSHandshakeTimeout time.Duration
+
+	CertificateReloadInterval time.Duration
+
+	MaxConnectionsPerIP  int
+	CIDRConnectionLimits map[string]int
+	AcceptRatePerIP      float64
+	AcceptBurstPerIP     int
+
+	IPFilter IPFilter
+
+	ServerFullRejection  bool
+	ServerFullEvent      uint32
+	ServerFullCloseCode  int
+	ServerFullRetryAfter time.Duration
+
+	Backpressure Backpressure
+
+	OutgoingQueue OutgoingQueue
+
+	WriteFlushDelay   time.Duration
+	WebSocketBatching bool
+
+	Scheduler Scheduler
+
+	MinWorkerCount           uint32
+	MaxWorkerCount           uint32
+	WorkerScaleUpQueuedTasks uint64
+	WorkerScaleUpQueueWait   time.Duration
+	WorkerIdleTimeout        time.Duration
+	WorkerScaleInterval      time.Duration
+
+	PriorityStarvationLimit uint32
+
+	MaxInFlightPerConnection uint32
+
+	FairScheduling        bool
+	FairSchedulingQuantum uint32
+
+	MaxQueueWait         time.Duration
+	LoadSheddingTarget   time.Duration
+	LoadSheddingInterval time.Duration
 }
 
 type ServerOption func(*ServerOptions)
@@ -70,6 +136,20 @@ func defaultServerOptions() ServerOptions {
 		MaxFrameLength:            1 << 20,
 		HeartbeatInterval:         5 * time.Second,
 		HeartbeatTimeout:          60 * time.Second,
+		WebSocketPing:             true,
+
+		WebSocketCompressionLevel:     1,
+		WebSocketCompressionThreshold: 256,
+
+		ProxyProtocolHeaderTimeout: 5 * time.Second,
+
+		TLSHandshakeTimeout: 10 * time.Second,
+
+		WorkerScaleUpQueueWait: 50 * time.Millisecond,
+		WorkerIdleTimeout:      30 * time.Second,
+		WorkerScaleInterval:    100 * time.Millisecond,
+
+		PriorityStarvationLimit: 16,
 	}
 }
 
@@ -119,6 +199,94 @@ func WithWorkerQueueCapacity(workerQueueCapacity uint32) ServerOption {
 	}
 }
 
+func WithWorkerAutoscaling(minWorkers, maxWorkers uint32) ServerOption {
+	return func(o *ServerOptions) {
+		o.MinWorkerCount = minWorkers
+		o.MaxWorkerCount = maxWorkers
+	}
+}
+
+func WithWorkerScaleUpThresholds(queuedTasks uint64, queueWait time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.WorkerScaleUpQueuedTasks = queuedTasks
+		o.WorkerScaleUpQueueWait = queueWait
+	}
+}
+
+func WithWorkerIdleTimeout(idleTimeout time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.WorkerIdleTimeout = idleTimeout
+	}
+}
+
+func WithWorkerScaleInterval(interval time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.WorkerScaleInterval = interval
+	}
+}
+
+func WithPriorityStarvationLimit(limit uint32) ServerOption {
+	return func(o *ServerOptions) {
+		o.PriorityStarvationLimit = limit
+	}
+}
+
+func WithMaxInFlightPerConnection(maxInFlight uint32) ServerOption {
+	return func(o *ServerOptions) {
+		o.MaxInFlightPerConnection = maxInFlight
+	}
+}
+
+func WithFairScheduling(quantum uint32) ServerOption {
+	return func(o *ServerOptions) {
+		o.FairScheduling = true
+		o.FairSchedulingQuantum = quantum
+	}
+}
+
+func WithMaxQueueWait(maxQueueWait time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.MaxQueueWait = maxQueueWait
+	}
+}
+
+func WithLoadShedding(target, interval time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.LoadSheddingTarget = target
+		o.LoadSheddingInterval = interval
+	}
+}
+
+func WithBackpressure(backpressure Backpressure) ServerOption {
+	return func(o *ServerOptions) {
+		o.Backpressure = backpressure
+	}
+}
+
+func WithOutgoingQueue(queue OutgoingQueue) ServerOption {
+	return func(o *ServerOptions) {
+		o.OutgoingQueue = queue
+	}
+}
+
+func WithWriteFlushDelay(delay time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.WriteFlushDelay = delay
+	}
+}
+
+func WithWebSocketBatching(enabled bool) ServerOption {
+	return func(o *ServerOptions) {
+		o.WebSocketBatching = enabled
+	}
+}
+
+func WithScheduler(scheduler Scheduler) ServerOption {
+	return func(o *ServerOptions) {
+		o.Scheduler = scheduler
+	}
+}
+
 func WithServerMaxFrameLength(maxFrameLength uint64) ServerOption {
 	return func(o *ServerOptions) {
 		o.MaxFrameLength = maxFrameLength
@@ -161,6 +329,61 @@ func WithWebSocketPath(webSocketPath string) ServerOption {
 	}
 }
 
+func WithWebSocketAllowedOrigins(origins ...string) ServerOption {
+	copied := append([]string(nil), origins...)
+	return func(o *ServerOptions) {
+		o.WebSocketAllowedOrigins = append([]string(nil), copied...)
+	}
+}
+
+func WithWebSocketCheckOrigin(checkOrigin func(*http.Request) bool) ServerOption {
+	return func(o *ServerOptions) {
+		o.WebSocketCheckOrigin = checkOrigin
+	}
+}
+
+func WithWebSocketSubprotocols(subprotocols ...string) ServerOption {
+	copied := append([]string(nil), subprotocols...)
+	return func(o *ServerOptions) {
+		o.WebSocketSubprotocols = append([]string(nil), copied...)
+	}
+}
+
+func WithWebSocketCompression(level int, threshold int) ServerOption {
+	return func(o *ServerOptions) {
+		o.WebSocketCompression = true
+		o.WebSocketCompressionLevel = level
+		o.WebSocketCompressionThreshold = threshold
+	}
+}
+
+func WithWebSocketTextSubprotocol(subprotocol string) ServerOption {
+	return func(o *ServerOptions) {
+		o.WebSocketTextSubprotocol = subprotocol
+	}
+}
+
+func WithProxyProtocol(trustedCIDRs ...string) ServerOption {
+	copied := append([]string(nil), trustedCIDRs...)
+	return func(o *ServerOptions) {
+		o.ProxyProtocol = true
+		o.ProxyProtocolTrustedCIDRs = append([]string(nil), copied...)
+	}
+}
+
+func WithProxyProtocolHeaderTimeout(headerTimeout time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.ProxyProtocolHeaderTimeout = headerTimeout
+	}
+}
+
+func WithWebSocketTrustedProxies(trustedCIDRs ...string) ServerOption {
+	copied := append([]string(nil), trustedCIDRs...)
+	return func(o *ServerOptions) {
+		o.WebSocketTrustedProxies = append([]string(nil), copied...)
+	}
+}
+
 func WithCertFile(certFile string) ServerOption {
 	return func(o *ServerOptions) {
 		o.CertFile = certFile
@@ -173,12 +396,88 @@ func WithPrivateKeyFile(privateKeyFile string) ServerOption {
 	}
 }
 
+func WithTLSConfig(config *tls.Config) ServerOption {
+	config = config.Clone()
+	return func(o *ServerOptions) {
+		o.TLSConfig = config.Clone()
+	}
+}
+
+func WithTLSTransports(transports ...Transport) ServerOption {
+	copied := append([]Transport(nil), transports...)
+	return func(o *ServerOptions) {
+		o.TLSTransports = append([]Transport(nil), copied...)
+	}
+}
+
+func WithTLSHandshakeTimeout(handshakeTimeout time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.TLSHandshakeTimeout = handshakeTimeout
+	}
+}
+
+func WithCertificateReloadInterval(interval time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.CertificateReloadInterval = interval
+	}
+}
+
 func WithMaxConnectionsCount(maxConnectionsCount int) ServerOption {
 	return func(o *ServerOptions) {
 		o.MaxConnectionsCount = maxConnectionsCount
 	}
 }
 
+func WithServerFullEvent(event uint32) ServerOption {
+	return func(o *ServerOptions) {
+		o.ServerFullRejection = true
+		o.ServerFullEvent = event
+	}
+}
+
+func WithServerFullCloseCode(closeCode int) ServerOption {
+	return func(o *ServerOptions) {
+		o.ServerFullCloseCode = closeCode
+	}
+}
+
+func WithServerFullRetryAfter(retryAfter time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.ServerFullRetryAfter = retryAfter
+	}
+}
+
+func WithIPFilter(filter IPFilter) ServerOption {
+	filter = filter.clone()
+	return func(o *ServerOptions) {
+		o.IPFilter = filter.clone()
+	}
+}
+
+func WithMaxConnectionsPerIP(maxConnections int) ServerOption {
+	return func(o *ServerOptions) {
+		o.MaxConnectionsPerIP = maxConnections
+	}
+}
+
+func WithCIDRConnectionLimit(cidr string, maxConnections int) ServerOption {
+	return func(o *ServerOptions) {
+		limits := make(map[string]int, len(o.CIDRConnectionLimits)+1)
+		for existing, limit := range o.CIDRConnectionLimits {
+			limits[existing] = limit
+		}
+		limits[cidr] = maxConnections
+		o.CIDRConnectionLimits = limits
+	}
+}
+
+func WithAcceptRateLimit(perSecond float64, burst int) ServerOption {
+	return func(o *ServerOptions) {
+		o.AcceptRatePerIP = perSecond
+		o.AcceptBurstPerIP = burst
+	}
+}
+
 func WithConnectionGroupsCount(connectionGroupsCount int) ServerOption {
 	return func(o *ServerOptions) {
 		o.ConnectionGroupsCount = connectionGroupsCount
@@ -209,6 +508,19 @@ func WithHeartbeatTimeout(heartbeatTimeout time.Duration) ServerOption {
 	}
 }
 
+func WithWebSocketPing(enabled bool) ServerOption {
+	return func(o *ServerOptions) {
+		o.WebSocketPing = enabled
+	}
+}
+
+func WithTCPPingEvent(event uint32) ServerOption {
+	return func(o *ServerOptions) {
+		o.TCPPing = true
+		o.TCPPingEvent = event
+	}
+}
+
 func validateServerOptions(opts ServerOptions) error {
 	if len(opts.Transports) == 0 {
 		return fmt.Errorf("%w: transports must not be empty", ErrInvalidConfiguration)
@@ -257,12 +569,62 @@ func validateServerOptions(opts ServerOptions) error {
 		if cleanedPath != opts.WebSocketPath {
 			return fmt.Errorf("%w: websocket path must be clean: %q", ErrInvalidConfiguration, opts.WebSocketPath)
 		}
+		if _, err := parseOriginPatterns(opts.WebSocketAllowedOrigins); err != nil {
+			return err
+		}
+		if opts.WebSocketCompressionLevel < -2 || opts.WebSocketCompressionLevel > 9 {
+			return fmt.Errorf("%w: websocket compression level must be between -2 and 9: %d", ErrInvalidConfiguration, opts.WebSocketCompressionLevel)
+		}
+		if opts.WebSocketCompressionThreshold < 0 {
+			return fmt.Errorf("%w: websocket compression threshold must not be negative: %d", ErrInvalidConfiguration, opts.WebSocketCompressionThreshold)
+		}
+		for _, subprotocol := range opts.WebSocketSubprotocols {
+			if !validSubprotocol(subprotocol) {
+				return fmt.Errorf("%w: invalid websocket subprotocol %q", ErrInvalidConfiguration, subprotocol)
+			}
+		}
+		if opts.WebSocketTextSubprotocol != "" && !validSubprotocol(opts.WebSocketTextSubprotocol) {
+			return fmt.Errorf("%w: invalid websocket text subprotocol %q", ErrInvalidConfiguration, opts.WebSocketTextSubprotocol)
+		}
 	}
 
 	if opts.MaxConnectionsCount <= 0 {
 		return fmt.Errorf("%w: max connections count must be positive: %d", ErrInvalidConfiguration, opts.MaxConnectionsCount)
 	}
 
+	if opts.ServerFullCloseCode != 0 && !validCloseCode(opts.ServerFullCloseCode) {
+		return fmt.Errorf("%w: invalid server full close code: %d", ErrInvalidConfiguration, opts.ServerFullCloseCode)
+	}
+
+	if opts.ServerFullRetryAfter < 0 {
+		return fmt.Errorf("%w: server full retry after must not be negative: %s", ErrInvalidConfiguration, opts.ServerFullRetryAfter)
+	}
+
+	if _, err := compileIPFilter(opts.IPFilter); err != nil {
+		return err
+	}
+
+	if opts.MaxConnectionsPerIP < 0 {
+		return fmt.Errorf("%w: max connections per ip must not be negative: %d", ErrInvalidConfiguration, opts.MaxConnectionsPerIP)
+	}
+
+	for cidr, limit := range opts.CIDRConnectionLimits {
+		if _, err := parseTrustedCIDRs([]string{cidr}); err != nil {
+			return err
+		}
+		if limit <= 0 {
+			return fmt.Errorf("%w: connection limit for %s must be positive: %d", ErrInvalidConfiguration, cidr, limit)
+		}
+	}
+
+	if opts.AcceptRatePerIP < 0 || math.IsNaN(opts.AcceptRatePerIP) || math.IsInf(opts.AcceptRatePerIP, 0) {
+		return fmt.Errorf("%w: accept rate per ip must be a non-negative number: %v", ErrInvalidConfiguration, opts.AcceptRatePerIP)
+	}
+
+	if opts.AcceptRatePerIP > 0 && opts.AcceptBurstPerIP < 1 {
+		return fmt.Errorf("%w: accept burst per ip must be at least 1: %d", ErrInvalidConfiguration, opts.AcceptBurstPerIP)
+	}
+
 	if opts.ConnectionGroupsCount <= 0 {
 		return fmt.Errorf("%w: connection groups count must be positive: %d", ErrInvalidConfiguration, opts.ConnectionGroupsCount)
 	}
@@ -282,6 +644,24 @@ func validateServerOptions(opts ServerOptions) error {
 	if opts.WorkerQueueCapacity == 0 {
 		return fmt.Errorf("%w: worker queue capacity must be positive", ErrInvalidConfiguration)
 	}
+	if err := validateBackpressure(opts.Backpressure); err != nil {
+		return err
+	}
+	if err := validateOutgoingQueue(opts.OutgoingQueue); err != nil {
+		return err
+	}
+	if opts.WriteFlushDelay < 0 {
+		return fmt.Errorf("%w: write flush delay must not be negative: %s", ErrInvalidConfiguration, opts.WriteFlushDelay)
+	}
+	if err := validateWorkerAutoscaling(opts); err != nil {
+		return err
+	}
+	if opts.PriorityStarvationLimit == 0 {
+		return fmt.Errorf("%w: priority starvation limit must be positive", ErrInvalidConfiguration)
+	}
+	if err := validateLoadShedding(opts); err != nil {
+		return err
+	}
 
 	if opts.MaxFrameLength == 0 {
 		return fmt.Errorf("%w: max frame length must be positive", ErrInvalidConfiguration)
@@ -307,6 +687,28 @@ func validateServerOptions(opts ServerOptions) error {
 		return fmt.Errorf("%w: cert file and private key file must be provided together", ErrInvalidConfiguration)
 	}
 
+	if err := validateTLSOptions(opts); err != nil {
+		return err
+	}
+
+	if opts.HasTransport(TransportTCP) && opts.ProxyProtocol {
+		if len(opts.ProxyProtocolTrustedCIDRs) == 0 {
+			return fmt.Errorf("%w: proxy protocol requires at least one trusted CIDR", ErrInvalidConfiguration)
+		}
+		if _, err := parseTrustedCIDRs(opts.ProxyProtocolTrustedCIDRs); err != nil {
+			return err
+		}
+		if opts.ProxyProtocolHeaderTimeout <= 0 {
+			return fmt.Errorf("%w: proxy protocol header timeout must be positive: %s", ErrInvalidConfiguration, opts.ProxyProtocolHeaderTimeout)
+		}
+	}
+
+	if opts.HasTransport(TransportWebSocket) {
+		if _, err := parseTrustedCIDRs(opts.WebSocketTrustedProxies); err != nil {
+			return err
+		}
+	}
+
 	if opts.HasTransport(TransportTCP) {
 		switch opts.IPVersion {
 		case "tcp", "tcp4", "tcp6":
@@ -317,3 +719,7 @@ func validateServerOptions(opts ServerOptions) error {
 
 	return nil
 }
+
+func validSubprotocol(subprotocol string) bool {
+	return subprotocol != "" && !strings.ContainsAny(subprotocol, " \t,;\"()<>@:/[]?={}")
+}
@@ -247,6 +247,289 @@ func TestValidateServerOptions(t *testing.T) {
 				return opts
 			}(),
 		},
+		{
+			name: "invalid websocket allowed origin",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithWebSocketAllowedOrigins("https://*")(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "invalid websocket subprotocol",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithWebSocketSubprotocols("chat, json")(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "websocket compression level out of range",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithWebSocketCompression(10, 0)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "negative websocket compression threshold",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithWebSocketCompression(1, -1)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "invalid websocket text subprotocol",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithWebSocketTextSubprotocol("ramix json")(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "invalid proxy protocol trusted cidr",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithProxyProtocol("10.0.0.0/33")(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "non-positive proxy protocol header timeout",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithProxyProtocol("10.0.0.0/8")(&opts)
+				WithProxyProtocolHeaderTimeout(0)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "invalid websocket trusted proxy",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithWebSocketTrustedProxies("proxy.internal")(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "negative certificate reload interval",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithCertFile("cert.pem")(&opts)
+				WithPrivateKeyFile("key.pem")(&opts)
+				WithCertificateReloadInterval(-time.Second)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "certificate reload interval without cert file",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithCertificateReloadInterval(time.Second)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "negative max connections per ip",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithMaxConnectionsPerIP(-1)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "invalid cidr connection limit",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithCIDRConnectionLimit("10.0.0.0/40", 1)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "non-positive cidr connection limit",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithCIDRConnectionLimit("10.0.0.0/8", 0)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "negative accept rate",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithAcceptRateLimit(-1, 1)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "accept rate without burst",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithAcceptRateLimit(1, 0)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "invalid ip filter",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithIPFilter(IPFilter{Deny: []string{"192.0.2.0/99"}})(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "invalid server full close code",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithServerFullCloseCode(1006)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "negative server full retry after",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithServerFullRetryAfter(-time.Second)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "block backpressure without timeout",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.Backpressure = Backpressure{Policy: BackpressureBlock}
+				return opts
+			}(),
+		},
+		{
+			name: "unsupported backpressure policy",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.Backpressure = Backpressure{Policy: BackpressurePolicy(9)}
+				return opts
+			}(),
+		},
+		{
+			name: "autoscaling without min workers",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.MaxWorkerCount = 4
+				return opts
+			}(),
+		},
+		{
+			name: "autoscaling max below min",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.MinWorkerCount = 4
+				opts.MaxWorkerCount = 2
+				return opts
+			}(),
+		},
+		{
+			name: "autoscaling without thresholds",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.MinWorkerCount = 1
+				opts.MaxWorkerCount = 2
+				opts.WorkerScaleUpQueueWait = 0
+				return opts
+			}(),
+		},
+		{
+			name: "autoscaling without idle timeout",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.MinWorkerCount = 1
+				opts.MaxWorkerCount = 2
+				opts.WorkerIdleTimeout = 0
+				return opts
+			}(),
+		},
+		{
+			name: "autoscaling without scale interval",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.MinWorkerCount = 1
+				opts.MaxWorkerCount = 2
+				WithWorkerScaleInterval(0)(&opts)
+				return opts
+			}(),
+		},
+		{
+			name: "zero priority starvation limit",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.PriorityStarvationLimit = 0
+				return opts
+			}(),
+		},
+		{
+			name: "negative max queue wait",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.MaxQueueWait = -time.Second
+				return opts
+			}(),
+		},
+		{
+			name: "load shedding without interval",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.LoadSheddingTarget = 5 * time.Millisecond
+				return opts
+			}(),
+		},
+		{
+			name: "load shedding interval below target",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.LoadSheddingTarget = 5 * time.Millisecond
+				opts.LoadSheddingInterval = time.Millisecond
+				return opts
+			}(),
+		},
+		{
+			name: "unsupported outgoing queue policy",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.OutgoingQueue = OutgoingQueue{Policy: OutgoingPolicy(99)}
+				return opts
+			}(),
+		},
+		{
+			name: "negative outgoing queue max bytes",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.OutgoingQueue = OutgoingQueue{MaxBytes: -1}
+				return opts
+			}(),
+		},
+		{
+			name: "negative slow consumer timeout",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.OutgoingQueue = OutgoingQueue{Policy: OutgoingDisconnect, SlowConsumerTimeout: -time.Second}
+				return opts
+			}(),
+		},
+		{
+			name: "negative write flush delay",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				opts.WriteFlushDelay = -time.Millisecond
+				return opts
+			}(),
+		},
+		{
+			name: "proxy protocol without trusted cidrs",
+			opts: func() ServerOptions {
+				opts := defaultServerOptions()
+				WithProxyProtocol()(&opts)
+				return opts
+			}(),
+		},
 		{
 			name: "invalid ip version",
 			opts: func() ServerOptions {
@@ -0,0 +1,227 @@
+package ramix
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type OutgoingPolicy uint8
+
+const (
+	OutgoingBlock OutgoingPolicy = iota
+	OutgoingDropNewest
+	OutgoingDropOldest
+	OutgoingDisconnect
+)
+
+func (p OutgoingPolicy) String() string {
+	switch p {
+	case OutgoingBlock:
+		return "block"
+	case OutgoingDropNewest:
+		return "drop-newest"
+	case OutgoingDropOldest:
+		return "drop-oldest"
+	case OutgoingDisconnect:
+		return "disconnect"
+	default:
+		return fmt.Sprintf("OutgoingPolicy(%d)", p)
+	}
+}
+
+type OutgoingQueue struct {
+	Policy              OutgoingPolicy
+	MaxBytes            int
+	SlowConsumerTimeout time.Duration
+}
+
+func validateOutgoingQueue(queue OutgoingQueue) error {
+	switch queue.Policy {
+	case OutgoingBlock, OutgoingDropNewest, OutgoingDropOldest, OutgoingDisconnect:
+	default:
+		return fmt.Errorf("%w: unsupported outgoing queue policy %q", ErrInvalidConfiguration, queue.Policy.String())
+	}
+	if queue.MaxBytes < 0 {
+		return fmt.Errorf("%w: outgoing queue max bytes must not be negative: %d", ErrInvalidConfiguration, queue.MaxBytes)
+	}
+	if queue.SlowConsumerTimeout < 0 {
+		return fmt.Errorf("%w: slow consumer timeout must not be negative: %s", ErrInvalidConfiguration, queue.SlowConsumerTimeout)
+	}
+	return nil
+}
+
+type outgoingQueue struct {
+	maxMessages int
+	maxBytes    int
+
+	mu       sync.Mutex
+	messages [][]byte
+	head     int
+	bytes    int
+	space    chan struct{}
+
+	ready chan struct{}
+}
+
+func newOutgoingQueue(maxMessages, maxBytes int) *outgoingQueue {
+	return &outgoingQueue{
+		maxMessages: maxMessages,
+		maxBytes:    maxBytes,
+		ready:       make(chan struct{}, 1),
+	}
+}
+
+func (q *outgoingQueue) fitsLocked(size int) bool {
+	count := q.lenLocked()
+	if count >= q.maxMessages {
+		return false
+	}
+	return q.maxBytes == 0 || count == 0 || q.bytes+size <= q.maxBytes
+}
+
+func (q *outgoingQueue) lenLocked() int {
+	return len(q.messages) - q.head
+}
+
+func (q *outgoingQueue) push(data []byte) (bool, <-chan struct{}) {
+	q.mu.Lock()
+	if !q.fitsLocked(len(data)) {
+		if q.space == nil {
+			q.space = make(chan struct{})
+		}
+		space := q.space
+		q.mu.Unlock()
+		return false, space
+	}
+	q.pushLocked(data)
+	q.mu.Unlock()
+	signal(q.ready)
+	return true, nil
+}
+
+func (q *outgoingQueue) pushDroppingOldest(data []byte) int {
+	q.mu.Lock()
+	var dropped int
+	for !q.fitsLocked(len(data)) {
+		q.popLocked()
+		dropped++
+	}
+	q.pushLocked(data)
+	q.mu.Unlock()
+	signal(q.ready)
+	return dropped
+}
+
+func (q *outgoingQueue) pushLocked(data []byte) {
+	if q.head > 0 && len(q.messages) == cap(q.messages) {
+		count := copy(q.messages, q.messages[q.head:])
+		for i := count; i < len(q.messages); i++ {
+			q.messages[i] = nil
+		}
+		q.messages = q.messages[:count]
+		q.head = 0
+	}
+	q.messages = append(q.messages, data)
+	q.bytes += len(data)
+}
+
+func (q *outgoingQueue) popAll(spent [][]byte) [][]byte {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.lenLocked() == 0 {
+		return nil
+	}
+
+	messages := q.messages[q.head:]
+	for i := range spent {
+		spent[i] = nil
+	}
+	q.messages = spent[:0]
+	q.head = 0
+	q.bytes = 0
+	q.wakeSendersLocked()
+	return messages
+}
+
+func (q *outgoingQueue) popLocked() []byte {
+	data := q.messages[q.head]
+	q.messages[q.head] = nil
+	q.head++
+	if q.head == len(q.messages) {
+		q.messages = q.messages[:0]
+		q.head = 0
+	}
+	q.bytes -= len(data)
+	q.wakeSendersLocked()
+	return data
+}
+
+func (q *outgoingQueue) wakeSendersLocked() {
+	if q.space != nil {
+		close(q.space)
+		q.space = nil
+	}
+}
+
+func (c *netConnection) enqueueOutgoing(ctx context.Context, data []byte) error {
+	return c.enqueueWithPolicy(ctx, data, c.server.OutgoingQueue.Policy)
+}
+
+func (c *netConnection) enqueueControl(ctx context.Context, data []byte) error {
+	return c.enqueueWithPolicy(ctx, data, OutgoingBlock)
+}
+
+func (c *netConnection) enqueueWithPolicy(ctx context.Context, data []byte, policy OutgoingPolicy) error {
+	config := c.server.OutgoingQueue
+	switch policy {
+	case OutgoingDropNewest:
+		if queued, _ := c.outgoing.push(data); !queued {
+			c.server.metrics.sendDropped(c.statsTransport())
+			return ErrOutgoingQueueFull
+		}
+		return nil
+	case OutgoingDropOldest:
+		for dropped := c.outgoing.pushDroppingOldest(data); dropped > 0; dropped-- {
+			c.server.metrics.sendDropped(c.statsTransport())
+		}
+		return nil
+	}
+
+	var slowConsumer <-chan time.Time
+	for {
+		queued, space := c.outgoing.push(data)
+		if queued {
+			return nil
+		}
+		if policy == OutgoingDisconnect && slowConsumer == nil {
+			if config.SlowConsumerTimeout == 0 {
+				return c.disconnectSlowConsumer()
+			}
+			timer := time.NewTimer(config.SlowConsumerTimeout)
+			defer timer.Stop()
+			slowConsumer = timer.C
+		}
+
+		select {
+		case <-space:
+		case <-slowConsumer:
+			return c.disconnectSlowConsumer()
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.sendCtx.Done():
+			return ErrConnectionClosed
+		case <-c.forceCtx.Done():
+			return ErrConnectionClosed
+		}
+	}
+}
+
+func (c *netConnection) disconnectSlowConsumer() error {
+	if c.tryRequestClose(OperationWrite, ErrSlowConsumer) {
+		c.server.metrics.slowConsumerDisconnected(c.statsTransport())
+		c.server.reportConnectionError(c.self, OperationWrite, ErrSlowConsumer)
+	}
+	return ErrSlowConsumer
+}
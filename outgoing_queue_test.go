@@ -0,0 +1,175 @@
+package ramix
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newOutgoingTestConnection starts a connection whose writer blocks on its
+// first message until the returned gate is closed.
+func newOutgoingTestConnection(t *testing.T, queueCapacity uint32, queue OutgoingQueue) (*Server, *netConnection, *fakeLifecycleTransport, chan struct{}) {
+	t.Helper()
+
+	writeGate := make(chan struct{})
+	transport := newFakeLifecycleTransport()
+	transport.writeGate = writeGate
+	transport.writeStarted = make(chan struct{})
+	server, _ := newLifecycleTestConnection(t, transport, queueCapacity)
+	server.OutgoingQueue = queue
+	connection, err := newNetConnection(1, server, TransportTCP, transport, transport.Write)
+	if err != nil {
+		t.Fatalf("newNetConnection() error = %v", err)
+	}
+	startLifecycleTestConnection(server, connection, transport)
+	t.Cleanup(func() {
+		connection.requestClose(OperationRead, net.ErrClosed)
+		_ = connection.wait(context.Background())
+	})
+
+	if err := connection.Send(context.Background(), 1, []byte("first")); err != nil {
+		t.Fatalf("Send(1) error = %v", err)
+	}
+	waitForSignal(t, transport.writeStarted, "first write start")
+	return server, connection, transport, writeGate
+}
+
+func drainOutgoingTestConnection(t *testing.T, connection *netConnection, transport *fakeLifecycleTransport, writeGate chan struct{}) []uint32 {
+	t.Helper()
+
+	close(writeGate)
+	if err := connection.stopSendsAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopSendsAndDrain() error = %v", err)
+	}
+	return messageEvents(transport.writtenMessages(t))
+}
+
+func TestOutgoingQueueDropNewestRejectsSendWhenFull(t *testing.T) {
+	server, connection, transport, writeGate := newOutgoingTestConnection(t, 1, OutgoingQueue{Policy: OutgoingDropNewest})
+
+	if err := connection.Send(context.Background(), 2, []byte("queued")); err != nil {
+		t.Fatalf("Send(2) error = %v", err)
+	}
+	if err := connection.Send(context.Background(), 3, []byte("dropped")); !errors.Is(err, ErrOutgoingQueueFull) {
+		t.Fatalf("Send(3) error = %v, want %v", err, ErrOutgoingQueueFull)
+	}
+	if got := server.Stats().TCP.DroppedSends; got != 1 {
+		t.Fatalf("TCP.DroppedSends = %d, want 1", got)
+	}
+
+	if got, want := drainOutgoingTestConnection(t, connection, transport, writeGate), []uint32{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("written events = %v, want %v", got, want)
+	}
+}
+
+func TestOutgoingQueueDropOldestLimitsQueuedBytes(t *testing.T) {
+	// Each message is an 8 byte header and a 4 byte body, so two fit.
+	server, connection, transport, writeGate := newOutgoingTestConnection(t, 16, OutgoingQueue{
+		Policy:   OutgoingDropOldest,
+		MaxBytes: 24,
+	})
+
+	for event := uint32(2); event <= 5; event++ {
+		if err := connection.Send(context.Background(), event, []byte("body")); err != nil {
+			t.Fatalf("Send(%d) error = %v", event, err)
+		}
+	}
+	if got := server.Stats().TCP.DroppedSends; got != 2 {
+		t.Fatalf("TCP.DroppedSends = %d, want 2", got)
+	}
+
+	if got, want := drainOutgoingTestConnection(t, connection, transport, writeGate), []uint32{1, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("written events = %v, want %v", got, want)
+	}
+}
+
+func TestOutgoingQueueBlockWakesEverySenderAsSpaceFrees(t *testing.T) {
+	_, connection, transport, writeGate := newOutgoingTestConnection(t, 1, OutgoingQueue{})
+
+	if err := connection.Send(context.Background(), 2, []byte("queued")); err != nil {
+		t.Fatalf("Send(2) error = %v", err)
+	}
+	results := make(chan error, 2)
+	for event := uint32(3); event <= 4; event++ {
+		go func(event uint32) {
+			results <- connection.Send(context.Background(), event, []byte("blocked"))
+		}(event)
+	}
+	assertNoErrorResult(t, results, "blocked send")
+
+	close(writeGate)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-results:
+			if err != nil {
+				t.Fatalf("blocked Send() error = %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("blocked Send() did not return after the writer freed space")
+		}
+	}
+	if err := connection.stopSendsAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopSendsAndDrain() error = %v", err)
+	}
+	if got := len(transport.writtenMessages(t)); got != 4 {
+		t.Fatalf("written message count = %d, want 4", got)
+	}
+}
+
+func TestOutgoingQueueDisconnectsSlowConsumer(t *testing.T) {
+	server, connection, _, _ := newOutgoingTestConnection(t, 1, OutgoingQueue{
+		Policy:              OutgoingDisconnect,
+		SlowConsumerTimeout: 20 * time.Millisecond,
+	})
+
+	if err := connection.Send(context.Background(), 2, []byte("queued")); err != nil {
+		t.Fatalf("Send(2) error = %v", err)
+	}
+	started := time.Now()
+	if err := connection.Send(context.Background(), 3, []byte("blocked")); !errors.Is(err, ErrSlowConsumer) {
+		t.Fatalf("Send(3) error = %v, want %v", err, ErrSlowConsumer)
+	}
+	if waited := time.Since(started); waited < 20*time.Millisecond {
+		t.Fatalf("Send(3) returned after %s, want at least the slow consumer timeout", waited)
+	}
+	if err := connection.wait(context.Background()); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+
+	operation, err := connection.closeReason()
+	if operation != OperationWrite || !errors.Is(err, ErrSlowConsumer) {
+		t.Fatalf("closeReason() = %s, %v, want %s, %v", operation, err, OperationWrite, ErrSlowConsumer)
+	}
+	if got := server.Stats().TCP.SlowConsumerDisconnects; got != 1 {
+		t.Fatalf("TCP.SlowConsumerDisconnects = %d, want 1", got)
+	}
+}
+
+func TestOutgoingQueuePingBypassesDropPolicy(t *testing.T) {
+	server, connection, transport, writeGate := newOutgoingTestConnection(t, 1, OutgoingQueue{Policy: OutgoingDropOldest})
+
+	if err := connection.Send(context.Background(), 2, []byte("queued")); err != nil {
+		t.Fatalf("Send(2) error = %v", err)
+	}
+	tcp := &TCPConnection{netConnection: connection}
+	pinged := make(chan error, 1)
+	go func() { pinged <- tcp.sendPingMessage([]byte("ping")) }()
+	assertNoErrorResult(t, pinged, "ping into a full queue")
+	if got := server.Stats().TCP.DroppedSends; got != 0 {
+		t.Fatalf("TCP.DroppedSends = %d, want 0", got)
+	}
+
+	close(writeGate)
+	if err := <-pinged; err != nil {
+		t.Fatalf("sendPingMessage() error = %v", err)
+	}
+	if err := connection.stopSendsAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopSendsAndDrain() error = %v", err)
+	}
+	if got, want := messageEvents(transport.writtenMessages(t)), []uint32{1, 2, server.TCPPingEvent}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("written events = %v, want %v", got, want)
+	}
+}
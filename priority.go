@@ -0,0 +1,49 @@
+package ramix
+
+import "fmt"
+
+type Priority int8
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+const priorityLevels = 3
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("Priority(%d)", p)
+	}
+}
+
+func (p Priority) level() int {
+	return int(PriorityHigh - p)
+}
+
+func validatePriority(priority Priority) error {
+	if priority < PriorityLow || priority > PriorityHigh {
+		return fmt.Errorf("%w: unknown route priority: %s", ErrInvalidConfiguration, priority)
+	}
+	return nil
+}
+
+func WithPriority(priority Priority) RouteOption {
+	return func(settings *routeSettings) {
+		settings.priority = priority
+	}
+}
+
+type PriorityQueueStats struct {
+	High   uint64
+	Normal uint64
+	Low    uint64
+}
@@ -0,0 +1,175 @@
+package ramix
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRouteGroup_RegisterRouteWithPriority(t *testing.T) {
+	rg := newGroup(newRouter())
+
+	if err := rg.RegisterRoute(1, func(*Context) {}, WithPriority(PriorityHigh)); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	if err := rg.RegisterRoute(2, func(*Context) {}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	if err := rg.RegisterRoute(3, func(*Context) {}, WithPriority(Priority(5))); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Fatalf("RegisterRoute() with unknown priority error = %v, want ErrInvalidConfiguration", err)
+	}
+
+	settings := rg.router.freezeSettings()
+	if settings[1].priority != PriorityHigh || settings[2].priority != PriorityNormal {
+		t.Error("Expected routes 1 and 2 to have high and normal priority, got", settings)
+	}
+	if _, ok := settings[3]; ok {
+		t.Error("Expected route with unknown priority not to be registered")
+	}
+}
+
+// runPriorityTasks blocks the only worker of pool, queues a task for each of
+// priorities and returns the order in which they ran once unblocked. check is
+// called while the tasks are queued.
+func runPriorityTasks(t *testing.T, pool *workerPool, metrics *serverMetrics, priorities []Priority, check func()) []Priority {
+	t.Helper()
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newMetricsContext(metrics, TransportTCP, 0)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+
+	ran := make(chan Priority, len(priorities))
+	for i, priority := range priorities {
+		task := newMetricsContext(metrics, TransportTCP, uint64(i+1))
+		task.priority = priority
+		task.handlers = []Handler{func(ctx *Context) { ran <- ctx.priority }}
+		if err := pool.submit(task); err != nil {
+			t.Fatalf("submit(%s) error = %v", priority, err)
+		}
+	}
+	check()
+
+	close(block)
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+	close(ran)
+
+	var order []Priority
+	for priority := range ran {
+		order = append(order, priority)
+	}
+	return order
+}
+
+func TestWorkerPoolRunsHigherPrioritiesFirst(t *testing.T) {
+	var metrics serverMetrics
+	pool := newWorkerPool(1, 8)
+	pool.start()
+
+	order := runPriorityTasks(t, pool, &metrics, []Priority{PriorityLow, PriorityNormal, PriorityLow, PriorityHigh}, func() {
+		want := PriorityQueueStats{High: 1, Normal: 1, Low: 2}
+		if got := metrics.snapshot().QueuedTasksByPriority; got != want {
+			t.Fatalf("queued tasks by priority = %+v, want %+v", got, want)
+		}
+	})
+
+	want := []Priority{PriorityHigh, PriorityNormal, PriorityLow, PriorityLow}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("run order = %v, want %v", order, want)
+	}
+	if got := metrics.snapshot().QueuedTasksByPriority; got != (PriorityQueueStats{}) {
+		t.Fatalf("queued tasks by priority after drain = %+v, want zero", got)
+	}
+}
+
+func TestWorkerPoolStarvationLimitRunsWaitingLowPriorityTask(t *testing.T) {
+	var metrics serverMetrics
+	pool := newWorkerPool(1, 8)
+	pool.starvationLimit = 2
+	pool.start()
+
+	order := runPriorityTasks(t, pool, &metrics, []Priority{PriorityLow, PriorityHigh, PriorityHigh, PriorityHigh, PriorityHigh}, func() {})
+
+	want := []Priority{PriorityHigh, PriorityHigh, PriorityLow, PriorityHigh, PriorityHigh}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("run order = %v, want %v", order, want)
+	}
+}
+
+func TestWorkerPoolStarvationLimitServesEveryWaitingPriority(t *testing.T) {
+	var metrics serverMetrics
+	pool := newWorkerPool(1, 16)
+	pool.starvationLimit = 2
+	pool.start()
+
+	priorities := []Priority{PriorityLow, PriorityLow, PriorityNormal, PriorityNormal}
+	for i := 0; i < 6; i++ {
+		priorities = append(priorities, PriorityHigh)
+	}
+	order := runPriorityTasks(t, pool, &metrics, priorities, func() {})
+
+	want := []Priority{
+		PriorityHigh, PriorityHigh, PriorityNormal, PriorityLow,
+		PriorityHigh, PriorityHigh, PriorityNormal, PriorityLow,
+		PriorityHigh, PriorityHigh,
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("run order = %v, want %v", order, want)
+	}
+}
+
+func TestWorkerPoolDropOldestEvictsLowestPriorityFirst(t *testing.T) {
+	pool := newWorkerPool(1, 2)
+	pool.start()
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newContext(context.Background(), &testConnection{id: 0}, nil)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	high := newContext(context.Background(), &testConnection{id: 1}, nil)
+	high.priority = PriorityHigh
+	highRan := make(chan struct{})
+	high.handlers = []Handler{func(*Context) { close(highRan) }}
+	low := newContext(context.Background(), &testConnection{id: 2}, nil)
+	low.priority = PriorityLow
+	low.handlers = []Handler{func(*Context) {
+		t.Error("evicted low priority task should not run")
+	}}
+	newest := newContext(context.Background(), &testConnection{id: 3}, nil)
+	newest.backpressure = Backpressure{Policy: BackpressureDropOldest}
+	newestRan := make(chan struct{})
+	newest.handlers = []Handler{func(*Context) { close(newestRan) }}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+	for _, task := range []*Context{high, low, newest} {
+		if err := pool.submit(task); err != nil {
+			t.Fatalf("submit() error = %v", err)
+		}
+	}
+	if low.Err() == nil {
+		t.Fatal("low priority task was not evicted")
+	}
+
+	close(block)
+	waitForSignal(t, highRan, "high priority task run")
+	waitForSignal(t, newestRan, "newest task run")
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+}
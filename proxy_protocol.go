@@ -0,0 +1,218 @@
+package ramix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	proxyProtocolV1Prefix  = []byte("PROXY ")
+	proxyProtocolV2Magic   = []byte("\r\n\r\n\x00\r\nQUIT\n")
+	proxyProtocolV1MaxSize = 107
+)
+
+type proxiedConn struct {
+	net.Conn
+	reader *bufio.Reader
+	remote net.Addr
+}
+
+func (c *proxiedConn) Read(data []byte) (int, error) {
+	return c.reader.Read(data)
+}
+
+func (c *proxiedConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *proxiedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func parseTrustedCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%w: invalid trusted address %q", ErrInvalidConfiguration, value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid trusted CIDR %q", ErrInvalidConfiguration, value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func addressIP(address net.Addr) net.IP {
+	switch address := address.(type) {
+	case *net.TCPAddr:
+		return address.IP
+	case *net.UDPAddr:
+		return address.IP
+	case nil:
+		return nil
+	default:
+		host, _, err := net.SplitHostPort(address.String())
+		if err != nil {
+			return nil
+		}
+		return net.ParseIP(host)
+	}
+}
+
+func (s *Server) trustsProxyProtocol(socket net.Conn) bool {
+	ip := addressIP(socket.RemoteAddr())
+	return ip != nil && containsIP(s.proxyProtocolTrusted, ip)
+}
+
+func (s *Server) readProxyHeader(socket net.Conn) (net.Conn, error) {
+	if err := socket.SetReadDeadline(time.Now().Add(s.ProxyProtocolHeaderTimeout)); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReaderSize(socket, 256)
+	remote, err := parseProxyHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := socket.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	if remote == nil {
+		remote = socket.RemoteAddr()
+	}
+	return &proxiedConn{Conn: socket, reader: reader, remote: remote}, nil
+}
+
+func parseProxyHeader(reader *bufio.Reader) (net.Addr, error) {
+	prefix, err := reader.Peek(len(proxyProtocolV1Prefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProxyProtocol, err)
+	}
+	if bytes.Equal(prefix, proxyProtocolV1Prefix) {
+		return parseProxyHeaderV1(reader)
+	}
+	magic, err := reader.Peek(len(proxyProtocolV2Magic))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProxyProtocol, err)
+	}
+	if bytes.Equal(magic, proxyProtocolV2Magic) {
+		return parseProxyHeaderV2(reader)
+	}
+	return nil, fmt.Errorf("%w: missing header", ErrProxyProtocol)
+}
+
+func parseProxyHeaderV1(reader *bufio.Reader) (net.Addr, error) {
+	line := make([]byte, 0, proxyProtocolV1MaxSize)
+	for {
+		value, err := reader.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrProxyProtocol, err)
+		}
+		line = append(line, value)
+		if value == '\n' {
+			break
+		}
+		if len(line) >= proxyProtocolV1MaxSize {
+			return nil, fmt.Errorf("%w: v1 header exceeds %d bytes", ErrProxyProtocol, proxyProtocolV1MaxSize)
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("%w: v1 header is not CRLF terminated", ErrProxyProtocol)
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("%w: v1 header has %d fields", ErrProxyProtocol, len(fields))
+	}
+	ip := net.ParseIP(fields[2])
+	switch {
+	case ip == nil:
+		return nil, fmt.Errorf("%w: invalid v1 source address %q", ErrProxyProtocol, fields[2])
+	case fields[1] == "TCP4" && ip.To4() == nil, fields[1] == "TCP6" && ip.To4() != nil:
+		return nil, fmt.Errorf("%w: v1 source address %q does not match %s", ErrProxyProtocol, fields[2], fields[1])
+	case fields[1] != "TCP4" && fields[1] != "TCP6":
+		return nil, fmt.Errorf("%w: unsupported v1 protocol %q", ErrProxyProtocol, fields[1])
+	}
+	if net.ParseIP(fields[3]) == nil {
+		return nil, fmt.Errorf("%w: invalid v1 destination address %q", ErrProxyProtocol, fields[3])
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid v1 source port %q", ErrProxyProtocol, fields[4])
+	}
+	if _, err := strconv.ParseUint(fields[5], 10, 16); err != nil {
+		return nil, fmt.Errorf("%w: invalid v1 destination port %q", ErrProxyProtocol, fields[5])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func parseProxyHeaderV2(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProxyProtocol, err)
+	}
+	if version := header[12] >> 4; version != 2 {
+		return nil, fmt.Errorf("%w: unsupported v2 version %d", ErrProxyProtocol, version)
+	}
+	command := header[12] & 0x0f
+	family := header[13]
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProxyProtocol, err)
+	}
+
+	switch command {
+	case 0x0:
+		return nil, nil
+	case 0x1:
+	default:
+		return nil, fmt.Errorf("%w: unsupported v2 command %d", ErrProxyProtocol, command)
+	}
+
+	switch family {
+	case 0x11:
+		if len(payload) < 12 {
+			return nil, fmt.Errorf("%w: short v2 IPv4 address block", ErrProxyProtocol)
+		}
+		ip := net.IP(append([]byte(nil), payload[0:4]...))
+		return &net.TCPAddr{IP: ip, Port: int(binary.BigEndian.Uint16(payload[8:10]))}, nil
+	case 0x21:
+		if len(payload) < 36 {
+			return nil, fmt.Errorf("%w: short v2 IPv6 address block", ErrProxyProtocol)
+		}
+		ip := net.IP(append([]byte(nil), payload[0:16]...))
+		return &net.TCPAddr{IP: ip, Port: int(binary.BigEndian.Uint16(payload[32:34]))}, nil
+	default:
+		return nil, nil
+	}
+}
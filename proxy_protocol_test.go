@@ -0,0 +1,110 @@
+package ramix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func proxyProtocolV2Header(command, family byte, addresses []byte) []byte {
+	header := append([]byte(nil), proxyProtocolV2Magic...)
+	header = append(header, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:16], uint16(len(addresses)))
+	return append(header, addresses...)
+}
+
+func TestParseProxyHeader(t *testing.T) {
+	ipv4 := []byte{203, 0, 113, 7, 10, 0, 0, 1, 0x10, 0x92, 0x22, 0xc3}
+	ipv6 := append(append(net.ParseIP("2001:db8::7").To16(), net.ParseIP("2001:db8::1").To16()...), 0x10, 0x92, 0x22, 0xc3)
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{name: "v1 tcp4", header: []byte("PROXY TCP4 203.0.113.7 10.0.0.1 4242 8899\r\n"), want: "203.0.113.7:4242"},
+		{name: "v1 tcp6", header: []byte("PROXY TCP6 2001:db8::7 2001:db8::1 4242 8899\r\n"), want: "[2001:db8::7]:4242"},
+		{name: "v1 unknown", header: []byte("PROXY UNKNOWN\r\n")},
+		{name: "v2 tcp4", header: proxyProtocolV2Header(0x1, 0x11, ipv4), want: "203.0.113.7:4242"},
+		{name: "v2 tcp6", header: proxyProtocolV2Header(0x1, 0x21, ipv6), want: "[2001:db8::7]:4242"},
+		{name: "v2 tcp4 with tlvs", header: proxyProtocolV2Header(0x1, 0x11, append(ipv4, 0x04, 0x00, 0x01, 0x00)), want: "203.0.113.7:4242"},
+		{name: "v2 local", header: proxyProtocolV2Header(0x0, 0x00, nil)},
+		{name: "v2 unix", header: proxyProtocolV2Header(0x1, 0x31, make([]byte, 216))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(io.MultiReader(bytes.NewReader(tt.header), strings.NewReader("payload")))
+			address, err := parseProxyHeader(reader)
+			if err != nil {
+				t.Fatalf("parseProxyHeader() error = %v", err)
+			}
+			got := ""
+			if address != nil {
+				got = address.String()
+			}
+			if got != tt.want {
+				t.Fatalf("parseProxyHeader() address = %q, want %q", got, tt.want)
+			}
+			rest, err := io.ReadAll(reader)
+			if err != nil || string(rest) != "payload" {
+				t.Fatalf("data after header = (%q, %v), want payload", rest, err)
+			}
+		})
+	}
+}
+
+func TestParseProxyHeaderRejectsInvalidHeaders(t *testing.T) {
+	tests := map[string][]byte{
+		"missing header":          []byte("\x01\x00\x00\x00\x05\x00\x00\x00hello"),
+		"v1 without crlf":         []byte("PROXY TCP4 203.0.113.7 10.0.0.1 4242 8899\n"),
+		"v1 too long":             []byte("PROXY TCP4 " + strings.Repeat("1", 120) + "\r\n"),
+		"v1 bad address":          []byte("PROXY TCP4 example.com 10.0.0.1 4242 8899\r\n"),
+		"v1 family mismatch":      []byte("PROXY TCP4 2001:db8::7 10.0.0.1 4242 8899\r\n"),
+		"v1 bad port":             []byte("PROXY TCP4 203.0.113.7 10.0.0.1 70000 8899\r\n"),
+		"v1 unsupported protocol": []byte("PROXY UDP4 203.0.113.7 10.0.0.1 4242 8899\r\n"),
+		"v1 truncated":            []byte("PROXY TCP4 203.0.113.7"),
+		"v2 bad version":          append(append([]byte(nil), proxyProtocolV2Magic...), 0x11, 0x11, 0, 0),
+		"v2 bad command":          proxyProtocolV2Header(0x2, 0x11, make([]byte, 12)),
+		"v2 short ipv4":           proxyProtocolV2Header(0x1, 0x11, make([]byte, 8)),
+		"v2 truncated":            proxyProtocolV2Header(0x1, 0x11, make([]byte, 12))[:20],
+	}
+
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseProxyHeader(bufio.NewReader(bytes.NewReader(header)))
+			if !errors.Is(err, ErrProxyProtocol) {
+				t.Fatalf("parseProxyHeader() error = %v, want %v", err, ErrProxyProtocol)
+			}
+		})
+	}
+}
+
+func TestParseTrustedCIDRs(t *testing.T) {
+	networks, err := parseTrustedCIDRs([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("parseTrustedCIDRs() error = %v", err)
+	}
+	for _, test := range []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "10.1.2.3", want: true},
+		{ip: "192.0.2.1", want: true},
+		{ip: "192.0.2.2"},
+		{ip: "2001:db8::7", want: true},
+		{ip: "2001:db9::7"},
+	} {
+		if got := containsIP(networks, net.ParseIP(test.ip)); got != test.want {
+			t.Fatalf("containsIP(%s) = %v, want %v", test.ip, got, test.want)
+		}
+	}
+
+	if _, err := parseTrustedCIDRs([]string{"10.0.0.0/33"}); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Fatalf("parseTrustedCIDRs(invalid) error = %v, want %v", err, ErrInvalidConfiguration)
+	}
+}
@@ -0,0 +1,95 @@
+package ramix
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type RateLimitAction uint8
+
+const (
+	RateLimitDrop RateLimitAction = iota
+	RateLimitThrottle
+	RateLimitClose
+)
+
+type RateLimitConfig struct {
+	Rate          float64
+	Burst         int
+	PerEvent      bool
+	Action        RateLimitAction
+	ThrottleEvent uint32
+}
+
+type rateLimitKey struct{ _ byte }
+
+type rateLimitState struct {
+	mu     sync.Mutex
+	bucket tokenBucket
+	events map[uint32]*tokenBucket
+}
+
+func RateLimit(rate float64, burst int) Handler {
+	return RateLimitWithConfig(RateLimitConfig{Rate: rate, Burst: burst})
+}
+
+func RateLimitWithConfig(config RateLimitConfig) Handler {
+	if !(config.Rate > 0) || math.IsInf(config.Rate, 0) {
+		panic("rate limit rate must be positive")
+	}
+	if config.Burst < 1 {
+		panic("rate limit burst must be at least 1")
+	}
+	key := &rateLimitKey{}
+	burst := float64(config.Burst)
+
+	return func(c *Context) {
+		local, ok := c.Connection.(interface {
+			connectionLocal(key any, create func() any) any
+		})
+		if !ok {
+			c.Next()
+			return
+		}
+		state := local.connectionLocal(key, func() any {
+			return &rateLimitState{bucket: newTokenBucket(burst, time.Now())}
+		}).(*rateLimitState)
+		if state.take(c.Request.Message.Event, config.PerEvent, config.Rate, burst) {
+			c.Next()
+			return
+		}
+
+		c.Abort()
+		c.messageThrottled()
+		switch config.Action {
+		case RateLimitThrottle:
+			_ = c.Connection.Send(c, config.ThrottleEvent, nil)
+		case RateLimitClose:
+			if closer, ok := c.Connection.(interface {
+				fail(ConnectionOperation, error)
+			}); ok {
+				closer.fail(OperationProtocol, ErrRateLimited)
+			}
+		}
+	}
+}
+
+func (s *rateLimitState) take(event uint32, perEvent bool, rate, burst float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if !perEvent {
+		return s.bucket.take(now, rate, burst)
+	}
+	if s.events == nil {
+		s.events = make(map[uint32]*tokenBucket)
+	}
+	bucket := s.events[event]
+	if bucket == nil {
+		created := newTokenBucket(burst, now)
+		bucket = &created
+		s.events[event] = bucket
+	}
+	return bucket.take(now, rate, burst)
+}
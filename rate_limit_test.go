@@ -0,0 +1,55 @@
+package ramix
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitStateTake(t *testing.T) {
+	t.Run("shared bucket", func(t *testing.T) {
+		state := &rateLimitState{bucket: newTokenBucket(2, time.Now())}
+		for i, want := range []bool{true, true, false} {
+			if got := state.take(uint32(i), false, 0.001, 2); got != want {
+				t.Fatalf("take(%d) = %v, want %v", i, got, want)
+			}
+		}
+	})
+
+	t.Run("per event buckets", func(t *testing.T) {
+		state := &rateLimitState{bucket: newTokenBucket(1, time.Now())}
+		for _, step := range []struct {
+			event uint32
+			want  bool
+		}{{1, true}, {1, false}, {2, true}, {2, false}} {
+			if got := state.take(step.event, true, 0.001, 1); got != step.want {
+				t.Fatalf("take(%d) = %v, want %v", step.event, got, step.want)
+			}
+		}
+	})
+}
+
+func TestRateLimitWithConfigPanicsOnInvalidConfig(t *testing.T) {
+	for name, config := range map[string]RateLimitConfig{
+		"zero rate":  {Rate: 0, Burst: 1},
+		"zero burst": {Rate: 1, Burst: 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("RateLimitWithConfig() did not panic")
+				}
+			}()
+			RateLimitWithConfig(config)
+		})
+	}
+}
+
+func TestRateLimitPassesThroughWithoutConnection(t *testing.T) {
+	called := false
+	c := &Context{step: -1}
+	c.handlers = []Handler{RateLimit(1, 1), func(*Context) { called = true }}
+	c.Next()
+	if !called {
+		t.Fatal("handler after RateLimit was not called")
+	}
+}
@@ -1,12 +1,35 @@
 package ramix
 
-import "sync"
+import (
+	"fmt"
+	"sync"
+	"time"
+)
 
 type Handler func(context *Context)
 
 type router struct {
-	mu     sync.RWMutex
-	routes map[uint32][]Handler
+	mu        sync.RWMutex
+	routes    map[uint32][]Handler
+	settings  map[uint32]routeSettings
+	bulkheads []*Bulkhead
+}
+
+type routeSettings struct {
+	backpressure     *Backpressure
+	orderIndependent bool
+	priority         Priority
+	maxQueueWait     *time.Duration
+	timeout          *TimeoutConfig
+	bulkhead         *Bulkhead
+}
+
+type RouteOption func(*routeSettings)
+
+func WithOrderIndependent() RouteOption {
+	return func(settings *routeSettings) {
+		settings.orderIndependent = true
+	}
 }
 
 type routeGroup struct {
@@ -14,10 +37,14 @@ type routeGroup struct {
 	router   *router
 	parent   *routeGroup
 	handlers []Handler
+	settings routeSettings
 }
 
 func newRouter() *router {
-	return &router{routes: make(map[uint32][]Handler)}
+	return &router{
+		routes:   make(map[uint32][]Handler),
+		settings: make(map[uint32]routeSettings),
+	}
 }
 
 func newGroup(router *router) *routeGroup {
@@ -27,12 +54,14 @@ func newGroup(router *router) *routeGroup {
 func (g *routeGroup) Group() *routeGroup {
 	g.router.mu.RLock()
 	handlers := append([]Handler(nil), g.handlers...)
+	settings := g.settings
 	g.router.mu.RUnlock()
 	return &routeGroup{
 		server:   g.server,
 		router:   g.router,
 		parent:   g,
 		handlers: handlers,
+		settings: settings,
 	}
 }
 
@@ -50,7 +79,7 @@ func (g *routeGroup) Use(handlers ...Handler) error {
 	return nil
 }
 
-func (g *routeGroup) RegisterRoute(event uint32, handler Handler) error {
+func (g *routeGroup) RegisterRoute(event uint32, handler Handler, options ...RouteOption) error {
 	if g.server != nil {
 		g.server.stateMu.Lock()
 		defer g.server.stateMu.Unlock()
@@ -60,11 +89,39 @@ func (g *routeGroup) RegisterRoute(event uint32, handler Handler) error {
 	}
 	g.router.mu.Lock()
 	handlers := append([]Handler(nil), g.handlers...)
+	settings := g.settings
+	for _, option := range options {
+		option(&settings)
+	}
+	if err := validateRouteSettings(settings); err != nil {
+		g.router.mu.Unlock()
+		return err
+	}
+	if settings.bulkhead != nil {
+		if err := g.router.registerBulkheadLocked(settings.bulkhead); err != nil {
+			g.router.mu.Unlock()
+			return err
+		}
+	}
+	if settings.timeout != nil {
+		handlers = append([]Handler{timeoutHandler(*settings.timeout)}, handlers...)
+	}
 	g.router.routes[event] = append(handlers, handler)
+	g.router.settings[event] = settings
 	g.router.mu.Unlock()
 	return nil
 }
 
+func validateRouteSettings(settings routeSettings) error {
+	if err := validatePriority(settings.priority); err != nil {
+		return err
+	}
+	if settings.maxQueueWait != nil && *settings.maxQueueWait < 0 {
+		return fmt.Errorf("%w: route max queue wait must not be negative: %s", ErrInvalidConfiguration, *settings.maxQueueWait)
+	}
+	return validateTimeout(settings.timeout)
+}
+
 func (r *router) freeze() map[uint32][]Handler {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -75,3 +132,14 @@ func (r *router) freeze() map[uint32][]Handler {
 	}
 	return frozen
 }
+
+func (r *router) freezeSettings() map[uint32]routeSettings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	frozen := make(map[uint32]routeSettings, len(r.settings))
+	for event, settings := range r.settings {
+		frozen[event] = settings
+	}
+	return frozen
+}
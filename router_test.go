@@ -39,3 +39,45 @@ func TestRouteGroup_RegisterRoute(t *testing.T) {
 		t.Error("Expected 1 route, got", len(rg.router.routes))
 	}
 }
+
+func TestRouteGroup_SetBackpressure(t *testing.T) {
+	rg := newGroup(newRouter())
+	if err := rg.RegisterRoute(1, func(*Context) {}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+
+	if err := rg.SetBackpressure(Backpressure{Policy: BackpressureBlock}); err == nil {
+		t.Fatal("SetBackpressure() accepted a block policy without timeout")
+	}
+	if err := rg.SetBackpressure(Backpressure{Policy: BackpressureDrop, BusyEvent: 503}); err != nil {
+		t.Fatalf("SetBackpressure() error = %v", err)
+	}
+	group := rg.Group()
+	if err := group.RegisterRoute(2, func(*Context) {}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+
+	settings := rg.router.freezeSettings()
+	if settings[1].backpressure != nil {
+		t.Error("Expected route registered before SetBackpressure to keep the server policy")
+	}
+	if got := settings[2].backpressure; got == nil || got.Policy != BackpressureDrop || got.BusyEvent != 503 {
+		t.Error("Expected route of child group to inherit the drop policy, got", got)
+	}
+}
+
+func TestRouteGroup_RegisterRouteWithOrderIndependent(t *testing.T) {
+	rg := newGroup(newRouter())
+
+	if err := rg.RegisterRoute(1, func(*Context) {}, WithOrderIndependent()); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	if err := rg.RegisterRoute(2, func(*Context) {}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+
+	settings := rg.router.freezeSettings()
+	if !settings[1].orderIndependent || settings[2].orderIndependent {
+		t.Error("Expected only route 1 to be order independent, got", settings)
+	}
+}
@@ -0,0 +1,91 @@
+package ramix
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+)
+
+type WorkerLoads interface {
+	Len() int
+	Load(i int) int
+}
+
+type Scheduler interface {
+	Schedule(task *Context, workers WorkerLoads) int
+}
+
+type SchedulerFunc func(task *Context, workers WorkerLoads) int
+
+func (f SchedulerFunc) Schedule(task *Context, workers WorkerLoads) int {
+	return f(task, workers)
+}
+
+func ConnectionAffinityScheduler() Scheduler {
+	return SchedulerFunc(func(task *Context, workers WorkerLoads) int {
+		if task.Connection == nil {
+			return 0
+		}
+		return int(task.Connection.ID() % uint64(workers.Len()))
+	})
+}
+
+func KeyAffinityScheduler() Scheduler {
+	connectionAffinity := ConnectionAffinityScheduler()
+	return SchedulerFunc(func(task *Context, workers WorkerLoads) int {
+		key, ok := task.AffinityKey()
+		if !ok {
+			return connectionAffinity.Schedule(task, workers)
+		}
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte(key))
+		return int(hash.Sum64() % uint64(workers.Len()))
+	})
+}
+
+func LeastLoadedScheduler() Scheduler {
+	return SchedulerFunc(leastLoadedWorker)
+}
+
+func leastLoadedWorker(_ *Context, workers WorkerLoads) int {
+	selected, selectedLoad := 0, workers.Load(0)
+	for i := 1; i < workers.Len() && selectedLoad > 0; i++ {
+		if load := workers.Load(i); load < selectedLoad {
+			selected, selectedLoad = i, load
+		}
+	}
+	return selected
+}
+
+type affinityKeyLocal struct{ _ byte }
+
+var affinityKeyLocalKey = &affinityKeyLocal{}
+
+func (c *Context) SetAffinityKey(key string) {
+	if slot := c.affinityKeySlot(); slot != nil {
+		slot.Store(&key)
+	}
+}
+
+func (c *Context) AffinityKey() (string, bool) {
+	slot := c.affinityKeySlot()
+	if slot == nil {
+		return "", false
+	}
+	key := slot.Load()
+	if key == nil || *key == "" {
+		return "", false
+	}
+	return *key, true
+}
+
+func (c *Context) affinityKeySlot() *atomic.Pointer[string] {
+	local, ok := c.Connection.(interface {
+		connectionLocal(key any, create func() any) any
+	})
+	if !ok {
+		return nil
+	}
+	return local.connectionLocal(affinityKeyLocalKey, func() any {
+		return &atomic.Pointer[string]{}
+	}).(*atomic.Pointer[string])
+}
@@ -0,0 +1,139 @@
+package ramix
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type localTestConnection struct {
+	testConnection
+	locals sync.Map
+}
+
+func (c *localTestConnection) connectionLocal(key any, create func() any) any {
+	if value, ok := c.locals.Load(key); ok {
+		return value
+	}
+	value, _ := c.locals.LoadOrStore(key, create())
+	return value
+}
+
+type testWorkerLoads []int
+
+func (l testWorkerLoads) Len() int       { return len(l) }
+func (l testWorkerLoads) Load(i int) int { return l[i] }
+
+func TestConnectionAffinityScheduler(t *testing.T) {
+	scheduler := ConnectionAffinityScheduler()
+	loads := testWorkerLoads{0, 0, 0}
+
+	for _, id := range []uint64{0, 1, 5, 9} {
+		task := newContext(context.Background(), &testConnection{id: id}, nil)
+		if got, want := scheduler.Schedule(task, loads), int(id%3); got != want {
+			t.Fatalf("Schedule(connection %d) = %d, want %d", id, got, want)
+		}
+	}
+	if got := scheduler.Schedule(newContext(context.Background(), nil, nil), loads); got != 0 {
+		t.Fatalf("Schedule(no connection) = %d, want 0", got)
+	}
+}
+
+func TestKeyAffinitySchedulerGroupsConnectionsByKey(t *testing.T) {
+	scheduler := KeyAffinityScheduler()
+	loads := make(testWorkerLoads, 16)
+
+	first := newContext(context.Background(), &localTestConnection{testConnection: testConnection{id: 1}}, nil)
+	second := newContext(context.Background(), &localTestConnection{testConnection: testConnection{id: 2}}, nil)
+	if got := scheduler.Schedule(second, loads); got != 2 {
+		t.Fatalf("Schedule(without key) = %d, want connection affinity 2", got)
+	}
+
+	first.SetAffinityKey("room-42")
+	second.SetAffinityKey("room-42")
+	if key, ok := second.AffinityKey(); !ok || key != "room-42" {
+		t.Fatalf("AffinityKey() = (%q, %v), want (%q, true)", key, ok, "room-42")
+	}
+	if a, b := scheduler.Schedule(first, loads), scheduler.Schedule(second, loads); a != b {
+		t.Fatalf("Schedule() = %d and %d for the same key, want equal workers", a, b)
+	}
+
+	second.SetAffinityKey("")
+	if _, ok := second.AffinityKey(); ok {
+		t.Fatal("AffinityKey() reported a cleared key")
+	}
+}
+
+func TestLeastLoadedScheduler(t *testing.T) {
+	scheduler := LeastLoadedScheduler()
+	task := newContext(context.Background(), &testConnection{id: 1}, nil)
+
+	for _, test := range []struct {
+		loads testWorkerLoads
+		want  int
+	}{
+		{loads: testWorkerLoads{3, 1, 2}, want: 1},
+		{loads: testWorkerLoads{0, 0, 0}, want: 0},
+		{loads: testWorkerLoads{2, 2, 0}, want: 2},
+	} {
+		if got := scheduler.Schedule(task, test.loads); got != test.want {
+			t.Fatalf("Schedule(%v) = %d, want %d", test.loads, got, test.want)
+		}
+	}
+}
+
+func TestWorkerPoolSchedulerAvoidsBusyWorker(t *testing.T) {
+	for name, configure := range map[string]func(*workerPool, *Context){
+		"least loaded scheduler": func(pool *workerPool, _ *Context) {
+			pool.scheduler = LeastLoadedScheduler()
+		},
+		"order independent route": func(_ *workerPool, task *Context) {
+			task.orderIndependent = true
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pool := newWorkerPool(2, 1)
+			block := make(chan struct{})
+			started := make(chan struct{})
+			first := newContext(context.Background(), &testConnection{id: 2}, nil)
+			first.handlers = []Handler{func(*Context) {
+				close(started)
+				<-block
+			}}
+			second := newContext(context.Background(), &testConnection{id: 2}, nil)
+			ran := make(chan struct{})
+			second.handlers = []Handler{func(*Context) { close(ran) }}
+			configure(pool, second)
+			pool.start()
+
+			if err := pool.submit(first); err != nil {
+				t.Fatalf("submit(first) error = %v", err)
+			}
+			waitForSignal(t, started, "first task start")
+			if err := pool.submit(second); err != nil {
+				t.Fatalf("submit(second) error = %v", err)
+			}
+			waitForSignal(t, ran, "second task on idle worker")
+
+			close(block)
+			if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+				t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolWrapsSchedulerIndex(t *testing.T) {
+	pool := newWorkerPool(3, 1)
+	for _, test := range []struct {
+		index int
+		want  int
+	}{{index: 4, want: 1}, {index: -1, want: 2}} {
+		index := test.index
+		pool.scheduler = SchedulerFunc(func(*Context, WorkerLoads) int { return index })
+		task := newContext(context.Background(), &testConnection{id: 0}, nil)
+		if got := pool.selectWorker(task); got != pool.workers[test.want] {
+			t.Fatalf("selectWorker(index %d) = worker %d, want %d", test.index, got.id, test.want)
+		}
+	}
+}
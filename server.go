@@ -20,6 +20,7 @@ type Server struct {
 	*routeGroup
 
 	upgrader            *websocket.Upgrader
+	originPatterns      []originPattern
 	currentConnectionID uint64
 	router              *router
 	workerPool          *workerPool
@@ -28,13 +29,21 @@ type Server struct {
 	connectionManager   *connectionManager
 	metrics             serverMetrics
 
-	connectionOpen  func(Connection)
-	connectionClose func(Connection)
-	connectionError ConnectionErrorHandler
-	runtimeRoutes   map[uint32][]Handler
-	runtimeOpen     func(Connection)
-	runtimeClose    func(Connection)
-	runtimeError    ConnectionErrorHandler
+	connectionOpen   func(Connection)
+	connectionClose  func(Connection)
+	connectionError  ConnectionErrorHandler
+	webSocketUpgrade WebSocketUpgradeHandler
+	runtimeRoutes    map[uint32][]Handler
+	runtimeSettings  map[uint32]routeSettings
+	runtimeOpen      func(Connection)
+	runtimeClose     func(Connection)
+	runtimeError     ConnectionErrorHandler
+	runtimeUpgrade   WebSocketUpgradeHandler
+
+	certificateReloadError CertificateReloadErrorHandler
+	runtimeReloadError     CertificateReloadErrorHandler
+	connectionRejected     ConnectionRejectedHandler
+	runtimeRejected        ConnectionRejectedHandler
 
 	stateMu       sync.Mutex
 	state         serverState
@@ -50,15 +59,25 @@ type Server struct {
 	serveGateOnce     sync.Once
 	shutdownStartOnce sync.Once
 
-	tcpListen       listenFunc
-	webSocketListen listenFunc
-	listeners       map[Transport]net.Listener
-	addresses       map[Transport]net.Addr
-	webSocketServer *http.Server
-	serviceWG       sync.WaitGroup
-	setupMu         sync.Mutex
-	acceptingSetups bool
-	setupWG         sync.WaitGroup
+	tcpListen             listenFunc
+	webSocketListen       listenFunc
+	listeners             map[Transport]net.Listener
+	addresses             map[Transport]net.Addr
+	webSocketServer       *http.Server
+	tlsConfig             *tls.Config
+	serviceWG             sync.WaitGroup
+	setupMu               sync.Mutex
+	acceptingSetups       bool
+	setupWG               sync.WaitGroup
+	pendingSockets        map[net.Conn]struct{}
+	pendingFullRejections atomic.Int32
+	pendingTCPSetups      atomic.Int32
+
+	proxyProtocolTrusted []*net.IPNet
+	trustedProxies       []*net.IPNet
+	certificates         *certificateReloader
+	ipLimiter            *ipLimiter
+	ipFilter             atomic.Pointer[compiledIPFilter]
 }
 
 func NewServer(serverOptions ...ServerOption) (*Server, error) {
@@ -85,16 +104,20 @@ func NewServer(serverOptions ...ServerOption) (*Server, error) {
 		tcpListen:       net.Listen,
 		webSocketListen: net.Listen,
 	}
-	server.upgrader = &websocket.Upgrader{
-		ReadBufferSize: int(server.ConnectionReadBufferSize),
-		CheckOrigin:    func(*http.Request) bool { return true },
+	if err := server.configureWebSocketUpgrader(); err != nil {
+		return nil, err
 	}
 	server.router = newRouter()
 	routeGroup := newGroup(server.router)
 	routeGroup.server = server
 	server.routeGroup = routeGroup
 	server.connectionManager = newConnectionManager(server.ConnectionGroupsCount)
-	server.workerPool = newWorkerPool(server.WorkerCount, server.WorkerQueueCapacity)
+	server.workerPool = server.newWorkerPool()
+	filter, err := compileIPFilter(server.IPFilter)
+	if err != nil {
+		return nil, err
+	}
+	server.ipFilter.Store(filter)
 	return server, nil
 }
 
@@ -119,11 +142,37 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 
 	s.runtimeRoutes = s.router.freeze()
+	s.runtimeSettings = s.router.freezeSettings()
 	s.runtimeOpen = s.connectionOpen
 	s.runtimeClose = s.connectionClose
 	s.runtimeError = s.connectionError
+	s.stateMu.Lock()
+	s.runtimeUpgrade = s.webSocketUpgrade
+	s.stateMu.Unlock()
+	s.runtimeReloadError = s.certificateReloadError
+	s.runtimeRejected = s.connectionRejected
+	if s.ProxyProtocol {
+		trusted, err := parseTrustedCIDRs(s.ProxyProtocolTrustedCIDRs)
+		if err != nil {
+			s.rollbackStartup()
+			return err
+		}
+		s.proxyProtocolTrusted = trusted
+	}
+	trustedProxies, err := parseTrustedCIDRs(s.WebSocketTrustedProxies)
+	if err != nil {
+		s.rollbackStartup()
+		return err
+	}
+	s.trustedProxies = trustedProxies
+	limiter, err := newIPLimiter(s.ServerOptions)
+	if err != nil {
+		s.rollbackStartup()
+		return err
+	}
+	s.ipLimiter = limiter
 	s.connectionManager = newConnectionManager(s.ConnectionGroupsCount)
-	s.workerPool = newWorkerPool(s.WorkerCount, s.WorkerQueueCapacity)
+	s.workerPool = s.newWorkerPool()
 	if err := s.prepareWebSocketServer(); err != nil {
 		s.rollbackStartup()
 		return err
@@ -282,6 +331,9 @@ func (s *Server) bindTransports(ctx context.Context) error {
 			listener, err = s.tcpListen(s.IPVersion, fmt.Sprintf("%s:%d", s.IP, s.Port))
 		case TransportWebSocket:
 			listener, err = s.webSocketListen("tcp", fmt.Sprintf("%s:%d", s.IP, s.WebSocketPort))
+			if err == nil && s.WebSocketCompression {
+				listener = countingListener{Listener: listener}
+			}
 		}
 		if err != nil {
 			return err
@@ -295,16 +347,16 @@ func (s *Server) bindTransports(ctx context.Context) error {
 }
 
 func (s *Server) applyTLS() error {
-	if s.CertFile == "" {
-		return nil
-	}
-	certificate, err := tls.LoadX509KeyPair(s.CertFile, s.PrivateKeyFile)
-	if err != nil {
+	config, err := s.buildTLSConfig()
+	if err != nil || config == nil {
 		return err
 	}
-	config := &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+	s.tlsConfig = config
 	for transport, listener := range s.listeners {
-		s.listeners[transport] = tls.NewListener(listener, config)
+		if transport == TransportTCP || !s.usesTLS(transport) {
+			continue
+		}
+		s.listeners[transport] = tls.NewListener(listener, s.tlsConfig)
 	}
 	return nil
 }
@@ -313,6 +365,9 @@ func (s *Server) prepareWebSocketServer() (err error) {
 	if !s.HasTransport(TransportWebSocket) {
 		return nil
 	}
+	if err := s.configureWebSocketUpgrader(); err != nil {
+		return err
+	}
 	defer func() {
 		if recovered := recover(); recovered != nil {
 			err = fmt.Errorf("%w: invalid websocket path %q: %v", ErrInvalidConfiguration, s.WebSocketPath, recovered)
@@ -325,6 +380,14 @@ func (s *Server) prepareWebSocketServer() (err error) {
 }
 
 func (s *Server) launchServices() {
+	if reloader := s.certificates; reloader != nil && s.CertificateReloadInterval > 0 {
+		s.serviceWG.Add(1)
+		go func() {
+			defer s.serviceWG.Done()
+			<-s.serveGate
+			reloader.watch(s.CertificateReloadInterval, s.reportCertificateReloadError)
+		}()
+	}
 	for transport, listener := range s.listeners {
 		s.serviceWG.Add(1)
 		go func(transport Transport, listener net.Listener) {
@@ -421,6 +484,9 @@ func (s *Server) shutdownOwner() {
 }
 
 func (s *Server) closeServingResources() {
+	if reloader := s.certificateReloader(); reloader != nil {
+		reloader.close()
+	}
 	if s.webSocketServer != nil {
 		_ = s.webSocketServer.Close()
 	}
@@ -446,6 +512,28 @@ func (s *Server) finishConnectionSetup() {
 func (s *Server) stopConnectionSetups() {
 	s.setupMu.Lock()
 	s.acceptingSetups = false
+	for socket := range s.pendingSockets {
+		_ = socket.Close()
+	}
+	s.setupMu.Unlock()
+}
+
+func (s *Server) trackPendingSocket(socket net.Conn) bool {
+	s.setupMu.Lock()
+	defer s.setupMu.Unlock()
+	if !s.acceptingSetups {
+		return false
+	}
+	if s.pendingSockets == nil {
+		s.pendingSockets = make(map[net.Conn]struct{})
+	}
+	s.pendingSockets[socket] = struct{}{}
+	return true
+}
+
+func (s *Server) untrackPendingSocket(socket net.Conn) {
+	s.setupMu.Lock()
+	delete(s.pendingSockets, socket)
 	s.setupMu.Unlock()
 }
 
@@ -474,7 +562,19 @@ func (s *Server) handleRequest(connection Connection, request *Request) error {
 	if provider, ok := connection.(interface{ taskContext() context.Context }); ok && provider.taskContext() != nil {
 		parent = provider.taskContext()
 	}
+	limiter, limited := connection.(interface {
+		acquireInFlight(stopping <-chan struct{}) error
+		releaseInFlight()
+	})
+	if limited {
+		if err := limiter.acquireInFlight(s.workerPool.stopping); err != nil {
+			return err
+		}
+	}
 	ctx := newContext(parent, connection, request)
+	if limited {
+		ctx.release = limiter.releaseInFlight
+	}
 	ctx.metrics = &s.metrics
 	ctx.metricTransport = transportForStats(connection)
 	routes := s.runtimeRoutes
@@ -488,13 +588,55 @@ func (s *Server) handleRequest(connection Connection, request *Request) error {
 			_ = ctx.Connection.Send(ctx, 404, []byte("Event Not Found"))
 		})
 	}
+	settings := s.routeSettingsFor(ctx.Request.Message.Event)
+	ctx.backpressure = s.backpressureFor(settings)
+	ctx.orderIndependent = settings.orderIndependent
+	ctx.priority = settings.priority
+	ctx.maxQueueWait = s.MaxQueueWait
+	if settings.maxQueueWait != nil {
+		ctx.maxQueueWait = *settings.maxQueueWait
+	}
+	ctx.bulkhead = settings.bulkhead
 	if err := s.workerPool.submit(ctx); err != nil {
 		ctx.finish()
+		if (ctx.backpressure.Policy == BackpressureDrop || ctx.backpressure.Policy == BackpressureDropOldest) && errors.Is(err, ErrWorkerQueueFull) {
+			s.replyBusy(connection, ctx.backpressure.BusyEvent)
+			return nil
+		}
 		return err
 	}
 	return nil
 }
 
+func (s *Server) newWorkerPool() *workerPool {
+	count := s.WorkerCount
+	if s.MaxWorkerCount > 0 {
+		count = s.MaxWorkerCount
+	}
+	pool := newWorkerPool(count, s.WorkerQueueCapacity)
+	pool.scheduler = s.Scheduler
+	pool.metrics = &s.metrics
+	pool.autoscale = newWorkerAutoscale(s.ServerOptions)
+	pool.starvationLimit = int(s.PriorityStarvationLimit)
+	for _, worker := range pool.workers {
+		worker.shedder = queueShedder{target: s.LoadSheddingTarget, interval: s.LoadSheddingInterval}
+		if s.FairScheduling {
+			for level := range worker.ordered {
+				worker.ordered[level] = newFairQueue(s.FairSchedulingQuantum)
+			}
+		}
+	}
+	return pool
+}
+
+func (s *Server) routeSettingsFor(event uint32) routeSettings {
+	settings := s.runtimeSettings
+	if settings == nil {
+		settings = s.router.freezeSettings()
+	}
+	return settings[event]
+}
+
 func (s *Server) OnConnectionOpen(callback func(Connection)) error {
 	s.stateMu.Lock()
 	defer s.stateMu.Unlock()
@@ -580,30 +722,57 @@ func (s *Server) reportConnectionError(connection Connection, operation Connecti
 	callback(connection, operation, err)
 }
 
-func (s *Server) openWebSocketConnection(socket *websocket.Conn, connectionID uint64) {
-	base, err := newNetConnection(connectionID, s, TransportWebSocket, socket, func(data []byte) error {
-		return socket.WriteMessage(websocket.BinaryMessage, data)
-	})
+func (s *Server) openWebSocketConnection(socket *websocket.Conn, connectionID uint64, remoteAddress net.Addr, attributes map[string]any, compression bool, release func()) {
+	connection := &WebSocketConnection{socket: socket}
+	base, err := newNetConnection(connectionID, s, TransportWebSocket, socket, connection.writeFrame)
 	if err != nil {
 		_ = socket.Close()
+		release()
 		return
 	}
-	connection := &WebSocketConnection{socket: socket, netConnection: base}
+	base.release = release
+	base.remoteAddress = remoteAddress
+	base.peerCertificate = verifiedPeerCertificate(socket.UnderlyingConn())
+	base.setAttributes(attributes)
+	base.subprotocol = socket.Subprotocol()
+	base.compression = compression
+	connection.netConnection = base
+	connection.textMessages = s.WebSocketTextSubprotocol != "" && socket.Subprotocol() == s.WebSocketTextSubprotocol
+	if s.WebSocketBatching && !connection.textMessages {
+		base.writeMessages = connection.writeFrames
+		base.maxBatchBytes = maxWebSocketBatchBytes(s.MaxFrameLength)
+	}
+	if compression {
+		if err := socket.SetCompressionLevel(s.WebSocketCompressionLevel); err != nil {
+			_ = socket.Close()
+			release()
+			return
+		}
+		connection.wireBytes = writtenBytesCounter(socket.UnderlyingConn())
+	}
 	s.connectionManager.addConnection(connection)
 	connection.open()
+	s.recheckIPFilter(connection)
 }
 
-func (s *Server) openTCPConnection(socket net.Conn, connectionID uint64) {
+func (s *Server) openTCPConnection(socket net.Conn, connectionID uint64, release func()) {
 	base, err := newNetConnection(connectionID, s, TransportTCP, socket, func(data []byte) error {
 		return writeFull(socket, data)
 	})
 	if err != nil {
 		_ = socket.Close()
+		release()
 		return
 	}
+	base.writeMessages = func(messages [][]byte) error {
+		return writeBuffers(socket, messages)
+	}
+	base.release = release
+	base.peerCertificate = verifiedPeerCertificate(socket)
 	connection := &TCPConnection{socket: socket, netConnection: base}
 	s.connectionManager.addConnection(connection)
 	connection.open()
+	s.recheckIPFilter(connection)
 }
 
 func (s *Server) nextConnectionID() uint64 {
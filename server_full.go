@@ -0,0 +1,144 @@
+package ramix
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const serverFullWriteTimeout = time.Second
+
+const maxPendingFullRejections = 64
+
+type ConnectionRejectedHandler func(transport Transport, remoteAddress net.Addr, err error)
+
+func (s *Server) OnConnectionRejected(callback ConnectionRejectedHandler) error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if err := s.mutationErrorLocked(); err != nil {
+		return err
+	}
+	s.connectionRejected = callback
+	return nil
+}
+
+func (s *Server) reportConnectionRejected(transport Transport, remoteAddress net.Addr, err error) {
+	callback := s.runtimeRejected
+	if callback == nil {
+		callback = s.connectionRejected
+	}
+	if callback == nil {
+		debug("%s connection from %s rejected: %v", transport, remoteAddress, err)
+		return
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			debug("Connection rejected handler panic: %v", recovered)
+		}
+	}()
+	callback(transport, remoteAddress, err)
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
+
+func (s *Server) serverFull() bool {
+	pending := int(s.pendingTCPSetups.Load())
+	return pending >= maxPendingTCPSetups || s.connectionManager.connectionsCount()+pending >= s.MaxConnectionsCount
+}
+
+func (s *Server) retryAfterSeconds() string {
+	if s.ServerFullRetryAfter <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(int64(math.Ceil(s.ServerFullRetryAfter.Seconds())), 10)
+}
+
+func (s *Server) rejectFullTCPConnection(socket net.Conn, proxied bool) {
+	notify := s.ServerFullRejection && !s.tcpTLS()
+	if proxied || notify {
+		if s.pendingFullRejections.Add(1) <= maxPendingFullRejections {
+			go func() {
+				defer s.finishConnectionSetup()
+				defer s.pendingFullRejections.Add(-1)
+				s.rejectPendingFullTCPConnection(socket, proxied, notify)
+			}()
+			return
+		}
+		s.pendingFullRejections.Add(-1)
+	}
+	s.metrics.serverFullRejected(TransportTCP)
+	s.reportConnectionRejected(TransportTCP, socket.RemoteAddr(), ErrServerFull)
+	_ = socket.Close()
+	s.finishConnectionSetup()
+}
+
+func (s *Server) rejectPendingFullTCPConnection(socket net.Conn, proxied, notify bool) {
+	defer socket.Close()
+	connection := socket
+	if s.trackPendingSocket(socket) {
+		defer s.untrackPendingSocket(socket)
+		if proxied {
+			proxiedConnection, err := s.readProxyHeader(socket)
+			if err != nil {
+				notify = false
+			} else {
+				release, err := s.admitClient(TransportTCP, proxiedConnection.RemoteAddr())
+				if err != nil {
+					return
+				}
+				release()
+				connection = proxiedConnection
+			}
+		}
+	} else {
+		notify = false
+	}
+	s.metrics.serverFullRejected(TransportTCP)
+	s.reportConnectionRejected(TransportTCP, connection.RemoteAddr(), ErrServerFull)
+	if !notify {
+		return
+	}
+
+	body := []byte(s.retryAfterSeconds())
+	frame, err := s.encoder.Encode(Message{Event: s.ServerFullEvent, Body: body, BodySize: uint32(len(body))})
+	if err != nil {
+		return
+	}
+	if err := connection.SetWriteDeadline(time.Now().Add(serverFullWriteTimeout)); err != nil {
+		return
+	}
+	_ = writeFull(connection, frame)
+}
+
+func (s *Server) rejectFullWebSocketUpgrade(writer http.ResponseWriter, request *http.Request) {
+	retryAfter := s.retryAfterSeconds()
+	if s.ServerFullCloseCode == 0 {
+		if retryAfter != "" {
+			writer.Header().Set("Retry-After", retryAfter)
+		}
+		http.Error(writer, "connection limit reached", http.StatusServiceUnavailable)
+		return
+	}
+	socket, err := s.upgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		return
+	}
+	defer socket.Close()
+	reason := "server full"
+	if retryAfter != "" {
+		reason = fmt.Sprintf("server full; retry after %s", retryAfter)
+	}
+	_ = socket.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(s.ServerFullCloseCode, reason), time.Now().Add(serverFullWriteTimeout))
+}
@@ -0,0 +1,41 @@
+package ramix
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidCloseCode(t *testing.T) {
+	for code, want := range map[int]bool{
+		999:  false,
+		1000: true,
+		1004: false,
+		1005: false,
+		1006: false,
+		1008: true,
+		1013: true,
+		1015: false,
+		2999: false,
+		3000: true,
+		4999: true,
+		5000: false,
+	} {
+		if got := validCloseCode(code); got != want {
+			t.Fatalf("validCloseCode(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestRetryAfterSecondsRoundsUp(t *testing.T) {
+	for retryAfter, want := range map[time.Duration]string{
+		0:                       "",
+		time.Second:             "1",
+		1500 * time.Millisecond: "2",
+		time.Millisecond:        "1",
+	} {
+		server := &Server{ServerOptions: ServerOptions{ServerFullRetryAfter: retryAfter}}
+		if got := server.retryAfterSeconds(); got != want {
+			t.Fatalf("retryAfterSeconds(%s) = %q, want %q", retryAfter, got, want)
+		}
+	}
+}
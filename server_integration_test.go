@@ -9,6 +9,7 @@ import (
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -168,12 +169,13 @@ func assertIntegrationShutdownInvariants(t *testing.T, server *Server) {
 	if got := len(server.connectionManager.finalizationSnapshot()); got != 0 {
 		t.Fatalf("finalizing connection count = %d, want 0", got)
 	}
-	for index, worker := range server.workerPool.workers {
-		select {
-		case <-worker.done:
-		default:
-			t.Fatalf("worker %d completion channel remains open", index)
-		}
+	select {
+	case <-server.workerPool.done:
+	default:
+		t.Fatal("worker pool completion channel remains open")
+	}
+	if got := server.Stats().Workers; got != 0 {
+		t.Fatalf("Workers after shutdown = %d, want 0", got)
 	}
 	servicesDone := make(chan struct{})
 	go func() {
@@ -350,8 +352,10 @@ func TestIntegration_TCPStatisticsSnapshot(t *testing.T) {
 	if err := waitForIntegrationRun(t, run); err != nil {
 		t.Fatalf("Run() error = %v", err)
 	}
-	if got := server.Stats(); got != closedStats {
-		t.Fatalf("Stats() after TCP shutdown = %+v, want preserved snapshot %+v", got, closedStats)
+	wantShutdown := closedStats
+	wantShutdown.Workers = 0
+	if got := server.Stats(); got != wantShutdown {
+		t.Fatalf("Stats() after TCP shutdown = %+v, want preserved snapshot %+v", got, wantShutdown)
 	}
 }
 
@@ -586,6 +590,47 @@ func TestIntegration_TCPTLSRequestResponse(t *testing.T) {
 	assertIntegrationMessage(t, response, 107, "echo:secure")
 }
 
+func TestIntegration_TCPTimeoutSuppressesLateReply(t *testing.T) {
+	server := newTCPIntegrationServer(t)
+	registerIntegrationEcho(t, server, 9, 109)
+	lateReply := make(chan error, 1)
+	if err := server.RegisterRoute(10, func(ctx *Context) {
+		<-ctx.Done()
+		lateReply <- ctx.Connection.Send(ctx, 110, []byte("late"))
+	}, WithTimeout(20*time.Millisecond)); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+	if _, err := client.Write(encodeIntegrationMessage(t, 10, "slow")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	response, err := readIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 408, "Request Timeout")
+	select {
+	case err := <-lateReply:
+		if !errors.Is(err, ErrRequestTimedOut) {
+			t.Fatalf("late Send() error = %v, want %v", err, ErrRequestTimedOut)
+		}
+	case <-time.After(integrationTimeout):
+		t.Fatal("handler did not reply")
+	}
+
+	if _, err := client.Write(encodeIntegrationMessage(t, 9, "next")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	response, err = readIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 109, "echo:next")
+}
+
 func TestIntegration_TCPShutdownDrainsAcceptedResponse(t *testing.T) {
 	server := newTCPIntegrationServer(t, WithShutdownTimeout(time.Second))
 	handlerStarted := make(chan struct{})
@@ -663,3 +708,912 @@ func assertIntegrationConnectionClosed(t *testing.T, err error) {
 		t.Fatalf("connection did not close before deadline: %v", err)
 	}
 }
+
+func TestIntegration_TCPPingEventMeasuresRoundTripTime(t *testing.T) {
+	server, err := NewServer(
+		WithTransports(TransportTCP),
+		WithIPVersion("tcp4"),
+		WithIP("127.0.0.1"),
+		WithPort(0),
+		WithHeartbeatInterval(20*time.Millisecond),
+		WithHeartbeatTimeout(time.Hour),
+		WithTCPPingEvent(900),
+	)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	routed := make(chan uint32, 8)
+	if err := server.Use(func(ctx *Context) {
+		routed <- ctx.Request.Message.Event
+		ctx.Next()
+	}); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+
+	ping, err := readIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("read ping error = %v", err)
+	}
+	if ping.Event != 900 || len(ping.Body) != 8 {
+		t.Fatalf("ping = (%d, %d bytes), want (900, 8 bytes)", ping.Event, len(ping.Body))
+	}
+	if _, err := client.Write(encodeIntegrationMessage(t, 900, string(ping.Body))); err != nil {
+		t.Fatalf("write pong error = %v", err)
+	}
+
+	deadline := time.Now().Add(integrationTimeout)
+	for {
+		var rtt time.Duration
+		for _, connection := range server.connectionManager.snapshot() {
+			info, _ := ConnectionInfoOf(connection)
+			rtt = info.RTT
+		}
+		if rtt > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("TCP RTT was not measured")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case event := <-routed:
+		t.Fatalf("pong event %d was routed to handlers", event)
+	default:
+	}
+}
+
+func registerIntegrationRemoteAddress(t *testing.T, server *Server, requestEvent, responseEvent uint32) {
+	t.Helper()
+	if err := server.RegisterRoute(requestEvent, func(ctx *Context) {
+		_ = ctx.Connection.Send(ctx, responseEvent, []byte(ctx.Connection.RemoteAddress().String()))
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+}
+
+func TestIntegration_TCPProxyProtocolReportsClientAddress(t *testing.T) {
+	server := newTCPIntegrationServer(t, WithProxyProtocol("127.0.0.0/8"))
+	registerIntegrationRemoteAddress(t, server, 31, 131)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	headers := map[string][]byte{
+		"v1": []byte("PROXY TCP4 203.0.113.7 10.0.0.1 4242 8899\r\n"),
+		"v2": proxyProtocolV2Header(0x1, 0x11, []byte{203, 0, 113, 7, 10, 0, 0, 1, 0x10, 0x92, 0x22, 0xc3}),
+	}
+	for name, header := range headers {
+		t.Run(name, func(t *testing.T) {
+			client := dialTCPIntegration(t, address)
+			setIntegrationDeadline(t, client)
+			if _, err := client.Write(append(header, encodeIntegrationMessage(t, 31, "")...)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			response, err := readIntegrationMessage(client)
+			if err != nil {
+				t.Fatalf("readIntegrationMessage() error = %v", err)
+			}
+			assertIntegrationMessage(t, response, 131, "203.0.113.7:4242")
+		})
+	}
+}
+
+func TestIntegration_TCPProxyProtocolRequiresHeaderFromTrustedPeer(t *testing.T) {
+	server := newTCPIntegrationServer(t,
+		WithProxyProtocol("127.0.0.1"),
+		WithProxyProtocolHeaderTimeout(50*time.Millisecond),
+	)
+	registerIntegrationRemoteAddress(t, server, 32, 132)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	missing := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, missing)
+	if _, err := missing.Write(encodeIntegrationMessage(t, 32, "")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	_, err := readIntegrationMessage(missing)
+	assertIntegrationConnectionClosed(t, err)
+
+	silent := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, silent)
+	_, err = readIntegrationMessage(silent)
+	assertIntegrationConnectionClosed(t, err)
+	if got := server.Stats().TCP.ActiveConnections; got != 0 {
+		t.Fatalf("TCP.ActiveConnections = %d, want 0", got)
+	}
+}
+
+func TestIntegration_TCPProxyProtocolIgnoresUntrustedPeer(t *testing.T) {
+	server := newTCPIntegrationServer(t, WithProxyProtocol("10.0.0.0/8"))
+	registerIntegrationRemoteAddress(t, server, 33, 133)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+	if _, err := client.Write(encodeIntegrationMessage(t, 33, "")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	response, err := readIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 133, client.LocalAddr().String())
+}
+
+func TestIntegration_TCPProxyProtocolRejectsHeaderFromUntrustedPeer(t *testing.T) {
+	server := newTCPIntegrationServer(t, WithProxyProtocol("10.0.0.0/8"))
+	registerIntegrationRemoteAddress(t, server, 35, 135)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+	header := []byte("PROXY TCP4 203.0.113.7 10.0.0.1 4242 8899\r\n")
+	if _, err := client.Write(append(header, encodeIntegrationMessage(t, 35, "")...)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	_, err := readIntegrationMessage(client)
+	assertIntegrationConnectionClosed(t, err)
+}
+
+func TestIntegration_TCPProxyProtocolPrecedesTLS(t *testing.T) {
+	server := newTCPIntegrationServer(t,
+		WithProxyProtocol("127.0.0.1"),
+		WithCertFile("examples/tls/public_certificate.pem"),
+		WithPrivateKeyFile("examples/tls/private_key.pem"),
+	)
+	registerIntegrationRemoteAddress(t, server, 34, 134)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	raw := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, raw)
+	if _, err := raw.Write([]byte("PROXY TCP6 2001:db8::7 2001:db8::1 4242 8899\r\n")); err != nil {
+		t.Fatalf("Write(header) error = %v", err)
+	}
+	// #nosec G402 -- test fixture intentionally bypasses certificate verification.
+	client := tls.Client(raw, &tls.Config{InsecureSkipVerify: true})
+	if _, err := client.Write(encodeIntegrationMessage(t, 34, "")); err != nil {
+		t.Fatalf("TLS Write() error = %v", err)
+	}
+	response, err := readIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 134, "[2001:db8::7]:4242")
+}
+
+func TestIntegration_TCPMutualTLSExposesClientCertificate(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	server := newTCPIntegrationServer(t,
+		WithTLSConfig(&tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  ca.pool(),
+			MinVersion: tls.VersionTLS12,
+		}),
+		WithCertFile("examples/tls/public_certificate.pem"),
+		WithPrivateKeyFile("examples/tls/private_key.pem"),
+		WithTLSHandshakeTimeout(time.Second),
+	)
+	if err := server.RegisterRoute(35, func(ctx *Context) {
+		info, _ := ConnectionInfoOf(ctx.Connection)
+		_ = ctx.Connection.Send(ctx, 135, []byte(info.PeerCertificate.Subject.CommonName))
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+	dialer := net.Dialer{Timeout: integrationTimeout}
+
+	// #nosec G402 -- test fixture intentionally bypasses server certificate verification.
+	config := &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{ca.issueClient(t, "alice")}}
+	client, err := tls.DialWithDialer(&dialer, "tcp", address.String(), config)
+	if err != nil {
+		t.Fatalf("tls.DialWithDialer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	setIntegrationDeadline(t, client)
+	if _, err := client.Write(encodeIntegrationMessage(t, 35, "")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	response, err := readIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 135, "alice")
+
+	// #nosec G402 -- test fixture intentionally bypasses server certificate verification.
+	anonymous, err := tls.DialWithDialer(&dialer, "tcp", address.String(), &tls.Config{InsecureSkipVerify: true})
+	if err == nil {
+		t.Cleanup(func() { _ = anonymous.Close() })
+		setIntegrationDeadline(t, anonymous)
+		_, err = readIntegrationMessage(anonymous)
+	}
+	if err == nil {
+		t.Fatal("client without certificate was accepted")
+	}
+	if got := server.Stats().TCP.ActiveConnections; got != 1 {
+		t.Fatalf("TCP.ActiveConnections = %d, want 1", got)
+	}
+}
+
+func TestIntegration_TCPReloadCertificatesKeepsExistingSessions(t *testing.T) {
+	files := newTestKeyPairFiles(t)
+	files.write(t, "first", time.Now().Add(time.Hour))
+	server := newTCPIntegrationServer(t,
+		WithCertFile(files.certFile),
+		WithPrivateKeyFile(files.keyFile),
+	)
+	registerIntegrationEcho(t, server, 37, 137)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	dial := func(want string) *tls.Conn {
+		t.Helper()
+		// #nosec G402 -- test fixture intentionally bypasses certificate verification.
+		config := &tls.Config{InsecureSkipVerify: true}
+		dialer := net.Dialer{Timeout: integrationTimeout}
+		client, err := tls.DialWithDialer(&dialer, "tcp", address.String(), config)
+		if err != nil {
+			t.Fatalf("tls.DialWithDialer() error = %v", err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		setIntegrationDeadline(t, client)
+		if got := client.ConnectionState().PeerCertificates[0].Subject.CommonName; got != want {
+			t.Fatalf("server certificate = %q, want %q", got, want)
+		}
+		return client
+	}
+	echo := func(client net.Conn, body string) {
+		t.Helper()
+		if _, err := client.Write(encodeIntegrationMessage(t, 37, body)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		response, err := readIntegrationMessage(client)
+		if err != nil {
+			t.Fatalf("readIntegrationMessage() error = %v", err)
+		}
+		assertIntegrationMessage(t, response, 137, "echo:"+body)
+	}
+
+	existing := dial("first")
+	echo(existing, "before")
+
+	files.writeRaw(t, []byte("not a certificate"), []byte("not a key"))
+	if err := server.ReloadCertificates(); err == nil {
+		t.Fatal("ReloadCertificates(invalid) error = nil, want error")
+	}
+	dial("first")
+
+	files.write(t, "second", time.Now().Add(time.Hour))
+	if err := server.ReloadCertificates(); err != nil {
+		t.Fatalf("ReloadCertificates() error = %v", err)
+	}
+	dial("second")
+	echo(existing, "after")
+}
+
+func TestIntegration_TCPWatchesCertificateFiles(t *testing.T) {
+	files := newTestKeyPairFiles(t)
+	files.write(t, "first", time.Now().Add(time.Hour))
+	server := newTCPIntegrationServer(t,
+		WithCertFile(files.certFile),
+		WithPrivateKeyFile(files.keyFile),
+		WithCertificateReloadInterval(5*time.Millisecond),
+	)
+	failures := make(chan error, 8)
+	if err := server.OnCertificateReloadError(func(err error) { failures <- err }); err != nil {
+		t.Fatalf("OnCertificateReloadError() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	files.writeRaw(t, []byte("not a certificate"), []byte("not a key"))
+	select {
+	case <-failures:
+	case <-time.After(integrationTimeout):
+		t.Fatal("reload failure was not reported")
+	}
+
+	files.write(t, "second", time.Now().Add(time.Hour))
+	deadline := time.Now().Add(integrationTimeout)
+	for {
+		// #nosec G402 -- test fixture intentionally bypasses certificate verification.
+		config := &tls.Config{InsecureSkipVerify: true}
+		dialer := net.Dialer{Timeout: integrationTimeout}
+		client, err := tls.DialWithDialer(&dialer, "tcp", address.String(), config)
+		if err != nil {
+			t.Fatalf("tls.DialWithDialer() error = %v", err)
+		}
+		served := client.ConnectionState().PeerCertificates[0].Subject.CommonName
+		_ = client.Close()
+		if served == "second" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server certificate = %q, want second", served)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestIntegration_TCPMaxConnectionsPerIPRejectsExtraClient(t *testing.T) {
+	server := newTCPIntegrationServer(t, WithMaxConnectionsPerIP(1))
+	registerIntegrationEcho(t, server, 38, 138)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	first := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, first)
+	if _, err := first.Write(encodeIntegrationMessage(t, 38, "first")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	response, err := readIntegrationMessage(first)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 138, "echo:first")
+
+	second := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, second)
+	_, err = readIntegrationMessage(second)
+	assertIntegrationConnectionClosed(t, err)
+	if got := server.Stats().TCP.RejectedIPConnections; got != 1 {
+		t.Fatalf("TCP.RejectedIPConnections = %d, want 1", got)
+	}
+
+	_ = first.Close()
+	waitForIntegrationStats(t, server, func(stats ServerStats) bool {
+		return stats.TCP.ActiveConnections == 0
+	}, "first connection to close")
+	third := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, third)
+	if _, err := third.Write(encodeIntegrationMessage(t, 38, "third")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	response, err = readIntegrationMessage(third)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 138, "echo:third")
+}
+
+func TestIntegration_TCPIPFilterUpdatesAtRuntime(t *testing.T) {
+	server := newTCPIntegrationServer(t, WithIPFilter(IPFilter{Deny: []string{"127.0.0.1"}}))
+	registerIntegrationEcho(t, server, 39, 139)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	denied := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, denied)
+	_, err := readIntegrationMessage(denied)
+	assertIntegrationConnectionClosed(t, err)
+	if got := server.Stats().TCP.RejectedDeniedIPs; got != 1 {
+		t.Fatalf("TCP.RejectedDeniedIPs = %d, want 1", got)
+	}
+
+	if _, err := server.SetIPFilter(IPFilter{Allow: []string{"127.0.0.0/8"}}, false); err != nil {
+		t.Fatalf("SetIPFilter() error = %v", err)
+	}
+	allowed := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, allowed)
+	if _, err := allowed.Write(encodeIntegrationMessage(t, 39, "allowed")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	response, err := readIntegrationMessage(allowed)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 139, "echo:allowed")
+
+	if evicted, err := server.SetIPFilter(IPFilter{Deny: []string{"127.0.0.0/8"}}, false); err != nil || evicted != 0 {
+		t.Fatalf("SetIPFilter(no eviction) = (%d, %v), want (0, nil)", evicted, err)
+	}
+	if got := server.Stats().TCP.ActiveConnections; got != 1 {
+		t.Fatalf("TCP.ActiveConnections = %d without eviction, want 1", got)
+	}
+	evicted, err := server.SetIPFilter(IPFilter{Deny: []string{"127.0.0.0/8"}}, true)
+	if err != nil || evicted != 1 {
+		t.Fatalf("SetIPFilter(evict) = (%d, %v), want (1, nil)", evicted, err)
+	}
+	_, err = readIntegrationMessage(allowed)
+	assertIntegrationConnectionClosed(t, err)
+}
+
+func TestIntegration_TCPServerFullSendsRejectionEvent(t *testing.T) {
+	server := newTCPIntegrationServer(t,
+		WithMaxConnectionsCount(1),
+		WithServerFullEvent(99),
+		WithServerFullRetryAfter(1500*time.Millisecond),
+	)
+	registerIntegrationEcho(t, server, 40, 140)
+	rejected := make(chan error, 1)
+	if err := server.OnConnectionRejected(func(transport Transport, remoteAddress net.Addr, err error) {
+		if transport == TransportTCP && remoteAddress != nil {
+			rejected <- err
+		}
+	}); err != nil {
+		t.Fatalf("OnConnectionRejected() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	first := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, first)
+	if _, err := first.Write(encodeIntegrationMessage(t, 40, "first")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := readIntegrationMessage(first); err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+
+	second := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, second)
+	response, err := readIntegrationMessage(second)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage(rejected) error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 99, "2")
+	_, err = readIntegrationMessage(second)
+	assertIntegrationConnectionClosed(t, err)
+
+	select {
+	case err := <-rejected:
+		if !errors.Is(err, ErrServerFull) {
+			t.Fatalf("rejection error = %v, want %v", err, ErrServerFull)
+		}
+	case <-time.After(integrationTimeout):
+		t.Fatal("rejection hook was not called")
+	}
+	if got := server.Stats().TCP.RejectedServerFull; got != 1 {
+		t.Fatalf("TCP.RejectedServerFull = %d, want 1", got)
+	}
+}
+
+func TestIntegration_TCPServerFullReportsProxiedClientAddress(t *testing.T) {
+	server := newTCPIntegrationServer(t,
+		WithMaxConnectionsCount(1),
+		WithProxyProtocol("127.0.0.1"),
+	)
+	registerIntegrationEcho(t, server, 41, 141)
+	rejected := make(chan net.Addr, 1)
+	if err := server.OnConnectionRejected(func(transport Transport, remoteAddress net.Addr, err error) {
+		rejected <- remoteAddress
+	}); err != nil {
+		t.Fatalf("OnConnectionRejected() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	first := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, first)
+	header := []byte("PROXY TCP4 203.0.113.7 10.0.0.1 4242 8899\r\n")
+	if _, err := first.Write(append(header, encodeIntegrationMessage(t, 41, "first")...)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := readIntegrationMessage(first); err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+
+	second := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, second)
+	if _, err := second.Write([]byte("PROXY TCP4 203.0.113.8 10.0.0.1 4343 8899\r\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	_, err := readIntegrationMessage(second)
+	assertIntegrationConnectionClosed(t, err)
+
+	select {
+	case remoteAddress := <-rejected:
+		if got := remoteAddress.String(); got != "203.0.113.8:4343" {
+			t.Fatalf("rejected remote address = %s, want 203.0.113.8:4343", got)
+		}
+	case <-time.After(integrationTimeout):
+		t.Fatal("rejection hook was not called")
+	}
+}
+
+func TestIntegration_TCPServerFullClosesAtOnceWhenRejectionsPile(t *testing.T) {
+	server := newTCPIntegrationServer(t,
+		WithMaxConnectionsCount(1),
+		WithServerFullEvent(99),
+	)
+	registerIntegrationEcho(t, server, 42, 142)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	first := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, first)
+	if _, err := first.Write(encodeIntegrationMessage(t, 42, "first")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := readIntegrationMessage(first); err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+
+	server.pendingFullRejections.Store(maxPendingFullRejections)
+	second := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, second)
+	_, err := readIntegrationMessage(second)
+	assertIntegrationConnectionClosed(t, err)
+	if got := server.pendingFullRejections.Load(); got != maxPendingFullRejections {
+		t.Fatalf("pending full rejections = %d, want %d", got, maxPendingFullRejections)
+	}
+	server.pendingFullRejections.Store(0)
+}
+
+func TestIntegration_TCPServerFullCountsPendingProxySetups(t *testing.T) {
+	server := newTCPIntegrationServer(t,
+		WithMaxConnectionsCount(1),
+		WithProxyProtocol("127.0.0.1"),
+	)
+	registerIntegrationEcho(t, server, 43, 143)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	pending := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, pending)
+	for i := 0; i < 4; i++ {
+		extra := dialTCPIntegration(t, address)
+		setIntegrationDeadline(t, extra)
+		header := []byte("PROXY TCP4 203.0.113.8 10.0.0.1 4343 8899\r\n")
+		if _, err := extra.Write(append(header, encodeIntegrationMessage(t, 43, "extra")...)); err != nil {
+			t.Fatalf("Write(extra) error = %v", err)
+		}
+		_, err := readIntegrationMessage(extra)
+		assertIntegrationConnectionClosed(t, err)
+	}
+
+	header := []byte("PROXY TCP4 203.0.113.7 10.0.0.1 4242 8899\r\n")
+	if _, err := pending.Write(append(header, encodeIntegrationMessage(t, 43, "pending")...)); err != nil {
+		t.Fatalf("Write(pending) error = %v", err)
+	}
+	response, err := readIntegrationMessage(pending)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 143, "echo:pending")
+	if got := server.Stats().TCP.ActiveConnections; got != 1 {
+		t.Fatalf("TCP.ActiveConnections = %d, want 1", got)
+	}
+}
+
+func TestIntegration_TCPServerFullCountsPendingTLSHandshakes(t *testing.T) {
+	server := newTCPIntegrationServer(t,
+		WithMaxConnectionsCount(1),
+		WithCertFile("examples/tls/public_certificate.pem"),
+		WithPrivateKeyFile("examples/tls/private_key.pem"),
+	)
+	registerIntegrationEcho(t, server, 44, 144)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	raw := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, raw)
+	// #nosec G402 -- test fixture intentionally bypasses certificate verification.
+	config := &tls.Config{InsecureSkipVerify: true}
+	for i := 0; i < 4; i++ {
+		extra := tls.Client(dialTCPIntegration(t, address), config)
+		setIntegrationDeadline(t, extra)
+		if err := extra.Handshake(); err == nil {
+			_, err = readIntegrationMessage(extra)
+			assertIntegrationConnectionClosed(t, err)
+		}
+	}
+
+	pending := tls.Client(raw, config)
+	if _, err := pending.Write(encodeIntegrationMessage(t, 44, "pending")); err != nil {
+		t.Fatalf("TLS Write() error = %v", err)
+	}
+	response, err := readIntegrationMessage(pending)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 144, "echo:pending")
+	if got := server.Stats().TCP.ActiveConnections; got != 1 {
+		t.Fatalf("TCP.ActiveConnections = %d, want 1", got)
+	}
+}
+
+func TestIntegration_TCPServerFullAppliesIPFilterFirst(t *testing.T) {
+	server := newTCPIntegrationServer(t, WithMaxConnectionsCount(1))
+	registerIntegrationEcho(t, server, 45, 145)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	first := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, first)
+	if _, err := first.Write(encodeIntegrationMessage(t, 45, "first")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := readIntegrationMessage(first); err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	if _, err := server.SetIPFilter(IPFilter{Deny: []string{"127.0.0.0/8"}}, false); err != nil {
+		t.Fatalf("SetIPFilter() error = %v", err)
+	}
+
+	denied := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, denied)
+	_, err := readIntegrationMessage(denied)
+	assertIntegrationConnectionClosed(t, err)
+	stats := server.Stats()
+	if stats.TCP.RejectedDeniedIPs != 1 || stats.TCP.RejectedServerFull != 0 {
+		t.Fatalf("TCP rejections = denied %d full %d, want denied 1 full 0", stats.TCP.RejectedDeniedIPs, stats.TCP.RejectedServerFull)
+	}
+}
+
+func TestIntegration_TCPServerFullAppliesIPFilterToProxiedClient(t *testing.T) {
+	server := newTCPIntegrationServer(t,
+		WithMaxConnectionsCount(1),
+		WithProxyProtocol("127.0.0.1"),
+		WithIPFilter(IPFilter{Deny: []string{"203.0.113.8"}}),
+	)
+	registerIntegrationEcho(t, server, 46, 146)
+	rejected := make(chan error, 1)
+	if err := server.OnConnectionRejected(func(transport Transport, remoteAddress net.Addr, err error) {
+		rejected <- err
+	}); err != nil {
+		t.Fatalf("OnConnectionRejected() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	first := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, first)
+	header := []byte("PROXY TCP4 203.0.113.7 10.0.0.1 4242 8899\r\n")
+	if _, err := first.Write(append(header, encodeIntegrationMessage(t, 46, "first")...)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := readIntegrationMessage(first); err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+
+	denied := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, denied)
+	if _, err := denied.Write([]byte("PROXY TCP4 203.0.113.8 10.0.0.1 4343 8899\r\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	_, err := readIntegrationMessage(denied)
+	assertIntegrationConnectionClosed(t, err)
+	select {
+	case err := <-rejected:
+		if !errors.Is(err, ErrIPDenied) {
+			t.Fatalf("rejection error = %v, want %v", err, ErrIPDenied)
+		}
+	case <-time.After(integrationTimeout):
+		t.Fatal("rejection hook was not called")
+	}
+	if got := server.Stats().TCP.RejectedServerFull; got != 0 {
+		t.Fatalf("TCP.RejectedServerFull = %d, want 0", got)
+	}
+}
+
+func TestIntegration_TCPRateLimitThrottlesConnection(t *testing.T) {
+	server := newTCPIntegrationServer(t)
+	if err := server.Use(RateLimitWithConfig(RateLimitConfig{
+		Rate:          0.001,
+		Burst:         2,
+		Action:        RateLimitThrottle,
+		ThrottleEvent: 429,
+	})); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	registerIntegrationEcho(t, server, 41, 141)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+	for _, body := range []string{"first", "second", "third"} {
+		if _, err := client.Write(encodeIntegrationMessage(t, 41, body)); err != nil {
+			t.Fatalf("Write(%s) error = %v", body, err)
+		}
+	}
+	for _, want := range []Message{
+		{Event: 141, Body: []byte("echo:first")},
+		{Event: 141, Body: []byte("echo:second")},
+		{Event: 429},
+	} {
+		response, err := readIntegrationMessage(client)
+		if err != nil {
+			t.Fatalf("readIntegrationMessage() error = %v", err)
+		}
+		assertIntegrationMessage(t, response, want.Event, string(want.Body))
+	}
+	if got := server.Stats().TCP.ThrottledMessages; got != 1 {
+		t.Fatalf("TCP.ThrottledMessages = %d, want 1", got)
+	}
+}
+
+func TestIntegration_TCPRateLimitClosesConnection(t *testing.T) {
+	server := newTCPIntegrationServer(t)
+	if err := server.Use(RateLimitWithConfig(RateLimitConfig{
+		Rate:   0.001,
+		Burst:  1,
+		Action: RateLimitClose,
+	})); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	registerIntegrationEcho(t, server, 42, 142)
+	reported := make(chan integrationError, 4)
+	if err := server.OnConnectionError(func(_ Connection, operation ConnectionOperation, err error) {
+		reported <- integrationError{operation: operation, err: err}
+	}); err != nil {
+		t.Fatalf("OnConnectionError() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+	if _, err := client.Write(encodeIntegrationMessage(t, 42, "allowed")); err != nil {
+		t.Fatalf("Write(allowed) error = %v", err)
+	}
+	response, err := readIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 142, "echo:allowed")
+	if _, err := client.Write(encodeIntegrationMessage(t, 42, "limited")); err != nil {
+		t.Fatalf("Write(limited) error = %v", err)
+	}
+	_, err = readIntegrationMessage(client)
+	assertIntegrationConnectionClosed(t, err)
+
+	failure := waitForIntegrationError(t, reported)
+	if failure.operation != OperationProtocol || !errors.Is(failure.err, ErrRateLimited) {
+		t.Fatalf("connection error = (%v, %v), want (%v, %v)", failure.operation, failure.err, OperationProtocol, ErrRateLimited)
+	}
+}
+
+func TestIntegration_TCPBackpressureDropRepliesBusy(t *testing.T) {
+	server := newTCPIntegrationServer(t, WithWorkerCount(1), WithWorkerQueueCapacity(1))
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	group := server.Group()
+	if err := group.SetBackpressure(Backpressure{Policy: BackpressureDrop, BusyEvent: 503}); err != nil {
+		t.Fatalf("SetBackpressure() error = %v", err)
+	}
+	started := make(chan struct{}, 2)
+	if err := group.RegisterRoute(43, func(ctx *Context) {
+		started <- struct{}{}
+		<-release
+		_ = ctx.Connection.Send(ctx, 143, ctx.Request.Message.Body)
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+	t.Cleanup(func() { releaseOnce.Do(func() { close(release) }) })
+
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+	if _, err := client.Write(encodeIntegrationMessage(t, 43, "running")); err != nil {
+		t.Fatalf("Write(running) error = %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(integrationTimeout):
+		t.Fatal("handler did not start")
+	}
+	for _, body := range []string{"queued", "dropped"} {
+		if _, err := client.Write(encodeIntegrationMessage(t, 43, body)); err != nil {
+			t.Fatalf("Write(%s) error = %v", body, err)
+		}
+	}
+	response, err := readIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage(busy) error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 503, "")
+
+	releaseOnce.Do(func() { close(release) })
+	for _, body := range []string{"running", "queued"} {
+		response, err := readIntegrationMessage(client)
+		if err != nil {
+			t.Fatalf("readIntegrationMessage(%s) error = %v", body, err)
+		}
+		assertIntegrationMessage(t, response, 143, body)
+	}
+	if got := server.Stats().TCP.RejectedTasks; got != 1 {
+		t.Fatalf("TCP.RejectedTasks = %d, want 1", got)
+	}
+}
+
+func TestIntegration_TCPRouteTimeoutRepliesAndFreesWorker(t *testing.T) {
+	server := newTCPIntegrationServer(t)
+	if err := server.RegisterRoute(45, func(ctx *Context) {
+		<-ctx.Done()
+	}, WithTimeoutConfig(TimeoutConfig{Timeout: 20 * time.Millisecond, Event: 504, Body: []byte("slow")})); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	registerIntegrationEcho(t, server, 46, 146)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+	for _, event := range []uint32{45, 46} {
+		if _, err := client.Write(encodeIntegrationMessage(t, event, "next")); err != nil {
+			t.Fatalf("Write(%d) error = %v", event, err)
+		}
+	}
+	for _, want := range []Message{
+		{Event: 504, Body: []byte("slow")},
+		{Event: 146, Body: []byte("echo:next")},
+	} {
+		response, err := readIntegrationMessage(client)
+		if err != nil {
+			t.Fatalf("readIntegrationMessage() error = %v", err)
+		}
+		assertIntegrationMessage(t, response, want.Event, string(want.Body))
+	}
+	if got := server.Stats().TCP.TimedOutRequests; got != 1 {
+		t.Fatalf("TCP.TimedOutRequests = %d, want 1", got)
+	}
+}
+
+func TestIntegration_TCPMaxInFlightPausesReader(t *testing.T) {
+	server := newTCPIntegrationServer(t, WithMaxInFlightPerConnection(1))
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	var inFlight atomic.Uint64
+	observe := func(ctx *Context) {
+		if got := ctx.Connection.(*TCPConnection).Info().InFlight; got > inFlight.Load() {
+			inFlight.Store(got)
+		}
+	}
+	if err := server.RegisterRoute(47, func(ctx *Context) {
+		observe(ctx)
+		started <- struct{}{}
+		<-release
+		observe(ctx)
+		_ = ctx.Connection.Send(ctx, 147, ctx.Request.Message.Body)
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+	var input []byte
+	for _, body := range []string{"first", "second", "third"} {
+		input = append(input, encodeIntegrationMessage(t, 47, body)...)
+	}
+	if _, err := client.Write(input); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	<-started
+	if got := server.Stats().TCP.QueuedTasks; got != 0 {
+		t.Fatalf("TCP.QueuedTasks while the first request runs = %d, want 0", got)
+	}
+
+	close(release)
+	for _, body := range []string{"first", "second", "third"} {
+		response, err := readIntegrationMessage(client)
+		if err != nil {
+			t.Fatalf("readIntegrationMessage() error = %v", err)
+		}
+		assertIntegrationMessage(t, response, 147, body)
+	}
+	if got := inFlight.Load(); got != 1 {
+		t.Fatalf("maximum in-flight requests = %d, want 1", got)
+	}
+}
+
+func TestIntegration_TCPWriteFlushDelayCoalescesReplies(t *testing.T) {
+	server := newTCPIntegrationServer(t, WithWriteFlushDelay(100*time.Millisecond))
+	if err := server.RegisterRoute(48, func(ctx *Context) {
+		for _, body := range []string{"first", "second", "third"} {
+			_ = ctx.Connection.Send(ctx, 148, []byte(body))
+		}
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	client := dialTCPIntegration(t, address)
+	setIntegrationDeadline(t, client)
+	if _, err := client.Write(encodeIntegrationMessage(t, 48, "burst")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, body := range []string{"first", "second", "third"} {
+		response, err := readIntegrationMessage(client)
+		if err != nil {
+			t.Fatalf("readIntegrationMessage() error = %v", err)
+		}
+		assertIntegrationMessage(t, response, 148, body)
+	}
+
+	stats := waitForIntegrationStats(t, server, func(stats ServerStats) bool {
+		return stats.TCP.SentMessages == 3
+	}, "three sent messages")
+	if stats.TCP.WriteBatches != 1 {
+		t.Fatalf("TCP.WriteBatches = %d, want 1", stats.TCP.WriteBatches)
+	}
+}
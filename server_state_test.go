@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -179,6 +181,18 @@ func TestRouterFreezeRejectsMutationWhileRunningAndAfterStop(t *testing.T) {
 	if err := server.OnConnectionOpen(func(Connection) {}); !errors.Is(err, ErrServerRunning) {
 		t.Fatalf("OnConnectionOpen(running) error = %v, want %v", err, ErrServerRunning)
 	}
+	if err := server.OnWebSocketUpgrade(func(*http.Request) (map[string]any, error) { return nil, nil }); !errors.Is(err, ErrServerRunning) {
+		t.Fatalf("OnWebSocketUpgrade(running) error = %v, want %v", err, ErrServerRunning)
+	}
+	if err := server.OnCertificateReloadError(func(error) {}); !errors.Is(err, ErrServerRunning) {
+		t.Fatalf("OnCertificateReloadError(running) error = %v, want %v", err, ErrServerRunning)
+	}
+	if err := server.OnConnectionRejected(func(Transport, net.Addr, error) {}); !errors.Is(err, ErrServerRunning) {
+		t.Fatalf("OnConnectionRejected(running) error = %v, want %v", err, ErrServerRunning)
+	}
+	if err := server.SetBackpressure(Backpressure{Policy: BackpressureDrop}); !errors.Is(err, ErrServerRunning) {
+		t.Fatalf("SetBackpressure(running) error = %v, want %v", err, ErrServerRunning)
+	}
 
 	if err := server.Shutdown(context.Background()); err != nil {
 		t.Fatalf("Shutdown() error = %v", err)
@@ -852,3 +866,25 @@ func TestServerRunJoinsServingErrorAndShutdownTimeout(t *testing.T) {
 	}
 	releaseOnce.Do(func() { close(release) })
 }
+
+func TestServerUpgradeHookReadWhileRegistering(t *testing.T) {
+	server, err := NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	request := httptest.NewRequest(http.MethodGet, "http://ramix.test/ws", nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_, _ = server.invokeUpgradeHook(request)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if err := server.OnWebSocketUpgrade(func(*http.Request) (map[string]any, error) { return nil, nil }); err != nil {
+			t.Fatalf("OnWebSocketUpgrade() error = %v", err)
+		}
+	}
+	<-done
+}
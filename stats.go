@@ -17,6 +17,13 @@ type ServerStats struct {
 	// WebSocket contains statistics accumulated over the server's lifetime for
 	// WebSocket connections.
 	WebSocket TransportStats
+	// Workers is the number of worker goroutines currently running. It changes
+	// over time when worker autoscaling is enabled and is zero while the
+	// server is not running.
+	Workers uint64
+	// QueuedTasksByPriority is the approximate number of tasks currently
+	// waiting in worker queues, by route priority.
+	QueuedTasksByPriority PriorityQueueStats
 }
 
 // TransportStats is an approximate point-in-time snapshot of one transport's
@@ -48,25 +55,95 @@ type TransportStats struct {
 	// MaximumRequestDuration is the greatest request duration observed over the
 	// server's lifetime.
 	MaximumRequestDuration time.Duration
+	// RejectedOrigins is the lifetime-cumulative number of WebSocket upgrades
+	// rejected by the origin policy.
+	RejectedOrigins uint64
+	// CompressedRawBytes is the lifetime-cumulative number of encoded message
+	// bytes sent with WebSocket compression, before compression.
+	CompressedRawBytes uint64
+	// CompressedWireBytes is the lifetime-cumulative number of bytes written to
+	// the socket while compressed WebSocket messages were written. It includes
+	// frame headers, TLS records and control frames written meanwhile, so it is
+	// not an exact compressed size.
+	CompressedWireBytes uint64
+	// RejectedIPConnections is the lifetime-cumulative number of connections
+	// rejected by a per-IP or per-CIDR connection limit.
+	RejectedIPConnections uint64
+	// RejectedAcceptRate is the lifetime-cumulative number of connections
+	// rejected by the per-IP accept rate limit.
+	RejectedAcceptRate uint64
+	// RejectedDeniedIPs is the lifetime-cumulative number of connections
+	// rejected by the IP filter.
+	RejectedDeniedIPs uint64
+	// RejectedServerFull is the lifetime-cumulative number of connections
+	// rejected because MaxConnectionsCount was reached.
+	RejectedServerFull uint64
+	// ThrottledMessages is the lifetime-cumulative number of messages discarded
+	// by RateLimit middleware.
+	ThrottledMessages uint64
+	// EvictedTasks is the lifetime-cumulative number of queued tasks discarded
+	// by the BackpressureDropOldest policy.
+	EvictedTasks uint64
+	// BlockedTasks is the lifetime-cumulative number of tasks whose connection
+	// reader waited for worker queue space under the BackpressureBlock policy.
+	BlockedTasks uint64
+	// ExpiredTasks is the lifetime-cumulative number of tasks dropped because
+	// they waited in a worker queue longer than their max queue wait.
+	ExpiredTasks uint64
+	// ShedTasks is the lifetime-cumulative number of tasks dropped by adaptive
+	// load shedding.
+	ShedTasks uint64
+	// TimedOutRequests is the lifetime-cumulative number of requests whose
+	// Timeout middleware deadline passed before their handlers returned.
+	TimedOutRequests uint64
+	// DroppedSends is the lifetime-cumulative number of outgoing messages
+	// discarded because the outgoing queue of their connection was full.
+	DroppedSends uint64
+	// SlowConsumerDisconnects is the lifetime-cumulative number of connections
+	// closed because they did not read their outgoing messages in time.
+	SlowConsumerDisconnects uint64
+	// WriteBatches is the lifetime-cumulative number of transport writes of
+	// outgoing messages. SentMessages divided by WriteBatches is the average
+	// number of messages coalesced into one write.
+	WriteBatches uint64
 }
 
 type serverMetrics struct {
 	tcp       transportMetrics
 	webSocket transportMetrics
+	workers   atomic.Uint64
+	// priorityQueuedTasks is indexed by Priority.level.
+	priorityQueuedTasks [priorityLevels]atomic.Uint64
 }
 
 type transportMetrics struct {
-	activeConnections      atomic.Uint64
-	queuedTasks            atomic.Uint64
-	receivedMessages       atomic.Uint64
-	receivedBytes          atomic.Uint64
-	sentMessages           atomic.Uint64
-	sentBytes              atomic.Uint64
-	rejectedTasks          atomic.Uint64
-	connectionErrors       atomic.Uint64
-	completedRequests      atomic.Uint64
-	totalRequestDuration   atomic.Uint64
-	maximumRequestDuration atomic.Uint64
+	activeConnections       atomic.Uint64
+	queuedTasks             atomic.Uint64
+	receivedMessages        atomic.Uint64
+	receivedBytes           atomic.Uint64
+	sentMessages            atomic.Uint64
+	sentBytes               atomic.Uint64
+	rejectedTasks           atomic.Uint64
+	connectionErrors        atomic.Uint64
+	completedRequests       atomic.Uint64
+	totalRequestDuration    atomic.Uint64
+	maximumRequestDuration  atomic.Uint64
+	rejectedOrigins         atomic.Uint64
+	compressedRawBytes      atomic.Uint64
+	compressedWireBytes     atomic.Uint64
+	rejectedIPConnections   atomic.Uint64
+	rejectedAcceptRate      atomic.Uint64
+	rejectedDeniedIPs       atomic.Uint64
+	rejectedServerFull      atomic.Uint64
+	throttledMessages       atomic.Uint64
+	evictedTasks            atomic.Uint64
+	blockedTasks            atomic.Uint64
+	expiredTasks            atomic.Uint64
+	shedTasks               atomic.Uint64
+	timedOutRequests        atomic.Uint64
+	droppedSends            atomic.Uint64
+	slowConsumerDisconnects atomic.Uint64
+	writeBatches            atomic.Uint64
 }
 
 // Stats returns a detached, approximate point-in-time snapshot of the server's
@@ -151,6 +228,14 @@ func (m *serverMetrics) taskDequeued(transport Transport) {
 	decrementGauge(&metrics.queuedTasks)
 }
 
+func (m *serverMetrics) priorityQueued(priority Priority) {
+	saturatingAdd(&m.priorityQueuedTasks[priority.level()], 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) priorityDequeued(priority Priority) {
+	decrementGauge(&m.priorityQueuedTasks[priority.level()])
+}
+
 func (m *serverMetrics) taskRejected(transport Transport) {
 	metrics := m.forTransport(transport)
 	if metrics == nil {
@@ -182,6 +267,127 @@ func (m *serverMetrics) requestCompleted(transport Transport, duration time.Dura
 	updateMaximum(&metrics.maximumRequestDuration, durationValue)
 }
 
+func (m *serverMetrics) originRejected(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.rejectedOrigins, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) messageCompressed(transport Transport, rawBytes, wireBytes uint64) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.compressedRawBytes, rawBytes, math.MaxUint64)
+	saturatingAdd(&metrics.compressedWireBytes, wireBytes, math.MaxUint64)
+}
+
+func (m *serverMetrics) ipConnectionsRejected(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.rejectedIPConnections, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) acceptRateRejected(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.rejectedAcceptRate, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) ipDenied(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.rejectedDeniedIPs, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) serverFullRejected(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.rejectedServerFull, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) messageThrottled(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.throttledMessages, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) taskEvicted(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.evictedTasks, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) taskBlocked(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.blockedTasks, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) taskExpired(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.expiredTasks, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) taskShed(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.shedTasks, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) requestTimedOut(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.timedOutRequests, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) sendDropped(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.droppedSends, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) slowConsumerDisconnected(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.slowConsumerDisconnects, 1, math.MaxUint64)
+}
+
+func (m *serverMetrics) batchWritten(transport Transport) {
+	metrics := m.forTransport(transport)
+	if metrics == nil {
+		return
+	}
+	saturatingAdd(&metrics.writeBatches, 1, math.MaxUint64)
+}
+
 func (m *serverMetrics) snapshot() ServerStats {
 	tcp := m.tcp.snapshot()
 	webSocket := m.webSocket.snapshot()
@@ -189,38 +395,76 @@ func (m *serverMetrics) snapshot() ServerStats {
 		Total:     combineTransportStats(tcp, webSocket),
 		TCP:       tcp,
 		WebSocket: webSocket,
+		Workers:   m.workers.Load(),
+		QueuedTasksByPriority: PriorityQueueStats{
+			High:   m.priorityQueuedTasks[PriorityHigh.level()].Load(),
+			Normal: m.priorityQueuedTasks[PriorityNormal.level()].Load(),
+			Low:    m.priorityQueuedTasks[PriorityLow.level()].Load(),
+		},
 	}
 }
 
 func (m *transportMetrics) snapshot() TransportStats {
 	return TransportStats{
-		ActiveConnections:      m.activeConnections.Load(),
-		QueuedTasks:            m.queuedTasks.Load(),
-		ReceivedMessages:       m.receivedMessages.Load(),
-		ReceivedBytes:          m.receivedBytes.Load(),
-		SentMessages:           m.sentMessages.Load(),
-		SentBytes:              m.sentBytes.Load(),
-		RejectedTasks:          m.rejectedTasks.Load(),
-		ConnectionErrors:       m.connectionErrors.Load(),
-		CompletedRequests:      m.completedRequests.Load(),
-		TotalRequestDuration:   counterDuration(m.totalRequestDuration.Load()),
-		MaximumRequestDuration: counterDuration(m.maximumRequestDuration.Load()),
+		ActiveConnections:       m.activeConnections.Load(),
+		QueuedTasks:             m.queuedTasks.Load(),
+		ReceivedMessages:        m.receivedMessages.Load(),
+		ReceivedBytes:           m.receivedBytes.Load(),
+		SentMessages:            m.sentMessages.Load(),
+		SentBytes:               m.sentBytes.Load(),
+		RejectedTasks:           m.rejectedTasks.Load(),
+		ConnectionErrors:        m.connectionErrors.Load(),
+		CompletedRequests:       m.completedRequests.Load(),
+		TotalRequestDuration:    counterDuration(m.totalRequestDuration.Load()),
+		MaximumRequestDuration:  counterDuration(m.maximumRequestDuration.Load()),
+		RejectedOrigins:         m.rejectedOrigins.Load(),
+		CompressedRawBytes:      m.compressedRawBytes.Load(),
+		CompressedWireBytes:     m.compressedWireBytes.Load(),
+		RejectedIPConnections:   m.rejectedIPConnections.Load(),
+		RejectedAcceptRate:      m.rejectedAcceptRate.Load(),
+		RejectedDeniedIPs:       m.rejectedDeniedIPs.Load(),
+		RejectedServerFull:      m.rejectedServerFull.Load(),
+		ThrottledMessages:       m.throttledMessages.Load(),
+		EvictedTasks:            m.evictedTasks.Load(),
+		BlockedTasks:            m.blockedTasks.Load(),
+		ExpiredTasks:            m.expiredTasks.Load(),
+		ShedTasks:               m.shedTasks.Load(),
+		TimedOutRequests:        m.timedOutRequests.Load(),
+		DroppedSends:            m.droppedSends.Load(),
+		SlowConsumerDisconnects: m.slowConsumerDisconnects.Load(),
+		WriteBatches:            m.writeBatches.Load(),
 	}
 }
 
 func combineTransportStats(first, second TransportStats) TransportStats {
 	return TransportStats{
-		ActiveConnections:      saturatedSum(first.ActiveConnections, second.ActiveConnections, math.MaxUint64),
-		QueuedTasks:            saturatedSum(first.QueuedTasks, second.QueuedTasks, math.MaxUint64),
-		ReceivedMessages:       saturatedSum(first.ReceivedMessages, second.ReceivedMessages, math.MaxUint64),
-		ReceivedBytes:          saturatedSum(first.ReceivedBytes, second.ReceivedBytes, math.MaxUint64),
-		SentMessages:           saturatedSum(first.SentMessages, second.SentMessages, math.MaxUint64),
-		SentBytes:              saturatedSum(first.SentBytes, second.SentBytes, math.MaxUint64),
-		RejectedTasks:          saturatedSum(first.RejectedTasks, second.RejectedTasks, math.MaxUint64),
-		ConnectionErrors:       saturatedSum(first.ConnectionErrors, second.ConnectionErrors, math.MaxUint64),
-		CompletedRequests:      saturatedSum(first.CompletedRequests, second.CompletedRequests, math.MaxUint64),
-		TotalRequestDuration:   time.Duration(saturatedSum(uint64(first.TotalRequestDuration), uint64(second.TotalRequestDuration), math.MaxInt64)),
-		MaximumRequestDuration: maxDuration(first.MaximumRequestDuration, second.MaximumRequestDuration),
+		ActiveConnections:       saturatedSum(first.ActiveConnections, second.ActiveConnections, math.MaxUint64),
+		QueuedTasks:             saturatedSum(first.QueuedTasks, second.QueuedTasks, math.MaxUint64),
+		ReceivedMessages:        saturatedSum(first.ReceivedMessages, second.ReceivedMessages, math.MaxUint64),
+		ReceivedBytes:           saturatedSum(first.ReceivedBytes, second.ReceivedBytes, math.MaxUint64),
+		SentMessages:            saturatedSum(first.SentMessages, second.SentMessages, math.MaxUint64),
+		SentBytes:               saturatedSum(first.SentBytes, second.SentBytes, math.MaxUint64),
+		RejectedTasks:           saturatedSum(first.RejectedTasks, second.RejectedTasks, math.MaxUint64),
+		ConnectionErrors:        saturatedSum(first.ConnectionErrors, second.ConnectionErrors, math.MaxUint64),
+		CompletedRequests:       saturatedSum(first.CompletedRequests, second.CompletedRequests, math.MaxUint64),
+		TotalRequestDuration:    time.Duration(saturatedSum(uint64(first.TotalRequestDuration), uint64(second.TotalRequestDuration), math.MaxInt64)),
+		MaximumRequestDuration:  maxDuration(first.MaximumRequestDuration, second.MaximumRequestDuration),
+		RejectedOrigins:         saturatedSum(first.RejectedOrigins, second.RejectedOrigins, math.MaxUint64),
+		CompressedRawBytes:      saturatedSum(first.CompressedRawBytes, second.CompressedRawBytes, math.MaxUint64),
+		CompressedWireBytes:     saturatedSum(first.CompressedWireBytes, second.CompressedWireBytes, math.MaxUint64),
+		RejectedIPConnections:   saturatedSum(first.RejectedIPConnections, second.RejectedIPConnections, math.MaxUint64),
+		RejectedAcceptRate:      saturatedSum(first.RejectedAcceptRate, second.RejectedAcceptRate, math.MaxUint64),
+		RejectedDeniedIPs:       saturatedSum(first.RejectedDeniedIPs, second.RejectedDeniedIPs, math.MaxUint64),
+		RejectedServerFull:      saturatedSum(first.RejectedServerFull, second.RejectedServerFull, math.MaxUint64),
+		ThrottledMessages:       saturatedSum(first.ThrottledMessages, second.ThrottledMessages, math.MaxUint64),
+		EvictedTasks:            saturatedSum(first.EvictedTasks, second.EvictedTasks, math.MaxUint64),
+		BlockedTasks:            saturatedSum(first.BlockedTasks, second.BlockedTasks, math.MaxUint64),
+		ExpiredTasks:            saturatedSum(first.ExpiredTasks, second.ExpiredTasks, math.MaxUint64),
+		ShedTasks:               saturatedSum(first.ShedTasks, second.ShedTasks, math.MaxUint64),
+		TimedOutRequests:        saturatedSum(first.TimedOutRequests, second.TimedOutRequests, math.MaxUint64),
+		DroppedSends:            saturatedSum(first.DroppedSends, second.DroppedSends, math.MaxUint64),
+		SlowConsumerDisconnects: saturatedSum(first.SlowConsumerDisconnects, second.SlowConsumerDisconnects, math.MaxUint64),
+		WriteBatches:            saturatedSum(first.WriteBatches, second.WriteBatches, math.MaxUint64),
 	}
 }
 
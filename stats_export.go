@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +20,23 @@ type statsJSONSnapshot struct {
 	Total     statsJSONTransport `json:"total"`
 	TCP       statsJSONTransport `json:"tcp"`
 	WebSocket statsJSONTransport `json:"websocket"`
+	Workers   uint64             `json:"workers"`
+
+	QueuedTasksByPriority statsJSONPriorityQueues `json:"queued_tasks_by_priority"`
+	Bulkheads             []statsJSONBulkhead     `json:"bulkheads"`
+}
+
+type statsJSONBulkhead struct {
+	Name     string `json:"name"`
+	Active   uint64 `json:"active"`
+	Queued   uint64 `json:"queued"`
+	Rejected uint64 `json:"rejected"`
+}
+
+type statsJSONPriorityQueues struct {
+	High   uint64 `json:"high"`
+	Normal uint64 `json:"normal"`
+	Low    uint64 `json:"low"`
 }
 
 type statsJSONTransport struct {
@@ -33,6 +51,22 @@ type statsJSONTransport struct {
 	CompletedRequests        uint64 `json:"completed_requests"`
 	TotalRequestDurationNS   int64  `json:"total_request_duration_ns"`
 	MaximumRequestDurationNS int64  `json:"maximum_request_duration_ns"`
+	RejectedOrigins          uint64 `json:"rejected_origins"`
+	CompressedRawBytes       uint64 `json:"compressed_raw_bytes"`
+	CompressedWireBytes      uint64 `json:"compressed_wire_bytes"`
+	RejectedIPConnections    uint64 `json:"rejected_ip_connections"`
+	RejectedAcceptRate       uint64 `json:"rejected_accept_rate"`
+	RejectedDeniedIPs        uint64 `json:"rejected_denied_ips"`
+	RejectedServerFull       uint64 `json:"rejected_server_full"`
+	ThrottledMessages        uint64 `json:"throttled_messages"`
+	EvictedTasks             uint64 `json:"evicted_tasks"`
+	BlockedTasks             uint64 `json:"blocked_tasks"`
+	ExpiredTasks             uint64 `json:"expired_tasks"`
+	ShedTasks                uint64 `json:"shed_tasks"`
+	TimedOutRequests         uint64 `json:"timed_out_requests"`
+	DroppedSends             uint64 `json:"dropped_sends"`
+	SlowConsumerDisconnects  uint64 `json:"slow_consumer_disconnects"`
+	WriteBatches             uint64 `json:"write_batches"`
 }
 
 var statsPrometheusMetrics = []prometheusMetric{
@@ -102,6 +136,102 @@ var statsPrometheusMetrics = []prometheusMetric{
 		typ:   "gauge",
 		value: prometheusDurationSeconds(func(stats TransportStats) time.Duration { return stats.MaximumRequestDuration }),
 	},
+	{
+		name:  "ramix_rejected_origins_total",
+		help:  "Lifetime-cumulative number of Ramix WebSocket upgrades rejected by the origin policy.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.RejectedOrigins }),
+	},
+	{
+		name:  "ramix_compressed_raw_bytes_total",
+		help:  "Lifetime-cumulative number of encoded Ramix message bytes sent with WebSocket compression, before compression.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.CompressedRawBytes }),
+	},
+	{
+		name:  "ramix_compressed_wire_bytes_total",
+		help:  "Lifetime-cumulative number of socket bytes written while Ramix wrote compressed WebSocket messages, including TLS and concurrent control frames.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.CompressedWireBytes }),
+	},
+	{
+		name:  "ramix_rejected_ip_connections_total",
+		help:  "Lifetime-cumulative number of Ramix connections rejected by per-IP or per-CIDR connection limits.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.RejectedIPConnections }),
+	},
+	{
+		name:  "ramix_rejected_accept_rate_total",
+		help:  "Lifetime-cumulative number of Ramix connections rejected by the per-IP accept rate limit.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.RejectedAcceptRate }),
+	},
+	{
+		name:  "ramix_rejected_denied_ips_total",
+		help:  "Lifetime-cumulative number of Ramix connections rejected by the IP filter.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.RejectedDeniedIPs }),
+	},
+	{
+		name:  "ramix_rejected_server_full_total",
+		help:  "Lifetime-cumulative number of Ramix connections rejected because the server was full.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.RejectedServerFull }),
+	},
+	{
+		name:  "ramix_throttled_messages_total",
+		help:  "Lifetime-cumulative number of Ramix messages discarded by rate limit middleware.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.ThrottledMessages }),
+	},
+	{
+		name:  "ramix_evicted_tasks_total",
+		help:  "Lifetime-cumulative number of queued Ramix request tasks discarded to make room for newer tasks.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.EvictedTasks }),
+	},
+	{
+		name:  "ramix_blocked_tasks_total",
+		help:  "Lifetime-cumulative number of Ramix request tasks that waited for worker queue space.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.BlockedTasks }),
+	},
+	{
+		name:  "ramix_expired_tasks_total",
+		help:  "Lifetime-cumulative number of Ramix request tasks dropped after exceeding their max queue wait.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.ExpiredTasks }),
+	},
+	{
+		name:  "ramix_shed_tasks_total",
+		help:  "Lifetime-cumulative number of Ramix request tasks dropped by adaptive load shedding.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.ShedTasks }),
+	},
+	{
+		name:  "ramix_timed_out_requests_total",
+		help:  "Lifetime-cumulative number of Ramix requests whose handler timeout fired.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.TimedOutRequests }),
+	},
+	{
+		name:  "ramix_dropped_sends_total",
+		help:  "Lifetime-cumulative number of Ramix outgoing messages dropped because their connection outgoing queue was full.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.DroppedSends }),
+	},
+	{
+		name:  "ramix_slow_consumer_disconnects_total",
+		help:  "Lifetime-cumulative number of Ramix connections closed as slow consumers.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.SlowConsumerDisconnects }),
+	},
+	{
+		name:  "ramix_write_batches_total",
+		help:  "Lifetime-cumulative number of Ramix transport writes of outgoing messages.",
+		typ:   "counter",
+		value: prometheusUint64(func(stats TransportStats) uint64 { return stats.WriteBatches }),
+	},
 }
 
 // StatsJSONHandler returns an HTTP handler that exports server statistics as JSON.
@@ -114,7 +244,7 @@ func StatsJSONHandler(server *Server) http.Handler {
 		if request.Method == http.MethodHead {
 			return
 		}
-		if err := json.NewEncoder(writer).Encode(statsJSONSnapshotFrom(server.Stats())); err != nil {
+		if err := json.NewEncoder(writer).Encode(statsJSONSnapshotFrom(server.Stats(), server.BulkheadStats())); err != nil {
 			return
 		}
 	})
@@ -131,7 +261,7 @@ func StatsPrometheusHandler(server *Server) http.Handler {
 		if request.Method == http.MethodHead {
 			return
 		}
-		writePrometheusStats(writer, server.Stats())
+		writePrometheusStats(writer, server.Stats(), server.BulkheadStats())
 	})
 }
 
@@ -152,12 +282,29 @@ func requireStatsExportServer(writer http.ResponseWriter, server *Server) bool {
 	return false
 }
 
-func statsJSONSnapshotFrom(stats ServerStats) statsJSONSnapshot {
-	return statsJSONSnapshot{
+func statsJSONSnapshotFrom(stats ServerStats, bulkheads []BulkheadStats) statsJSONSnapshot {
+	snapshot := statsJSONSnapshot{
 		Total:     statsJSONTransportFrom(stats.Total),
 		TCP:       statsJSONTransportFrom(stats.TCP),
 		WebSocket: statsJSONTransportFrom(stats.WebSocket),
+		Workers:   stats.Workers,
+
+		QueuedTasksByPriority: statsJSONPriorityQueues{
+			High:   stats.QueuedTasksByPriority.High,
+			Normal: stats.QueuedTasksByPriority.Normal,
+			Low:    stats.QueuedTasksByPriority.Low,
+		},
 	}
+	snapshot.Bulkheads = make([]statsJSONBulkhead, 0, len(bulkheads))
+	for _, bulkhead := range bulkheads {
+		snapshot.Bulkheads = append(snapshot.Bulkheads, statsJSONBulkhead{
+			Name:     bulkhead.Name,
+			Active:   bulkhead.Active,
+			Queued:   bulkhead.Queued,
+			Rejected: bulkhead.Rejected,
+		})
+	}
+	return snapshot
 }
 
 func statsJSONTransportFrom(stats TransportStats) statsJSONTransport {
@@ -173,18 +320,63 @@ func statsJSONTransportFrom(stats TransportStats) statsJSONTransport {
 		CompletedRequests:        stats.CompletedRequests,
 		TotalRequestDurationNS:   int64(stats.TotalRequestDuration),
 		MaximumRequestDurationNS: int64(stats.MaximumRequestDuration),
+		RejectedOrigins:          stats.RejectedOrigins,
+		CompressedRawBytes:       stats.CompressedRawBytes,
+		CompressedWireBytes:      stats.CompressedWireBytes,
+		RejectedIPConnections:    stats.RejectedIPConnections,
+		RejectedAcceptRate:       stats.RejectedAcceptRate,
+		RejectedDeniedIPs:        stats.RejectedDeniedIPs,
+		RejectedServerFull:       stats.RejectedServerFull,
+		ThrottledMessages:        stats.ThrottledMessages,
+		EvictedTasks:             stats.EvictedTasks,
+		BlockedTasks:             stats.BlockedTasks,
+		ExpiredTasks:             stats.ExpiredTasks,
+		ShedTasks:                stats.ShedTasks,
+		TimedOutRequests:         stats.TimedOutRequests,
+		DroppedSends:             stats.DroppedSends,
+		SlowConsumerDisconnects:  stats.SlowConsumerDisconnects,
+		WriteBatches:             stats.WriteBatches,
 	}
 }
 
-func writePrometheusStats(writer http.ResponseWriter, stats ServerStats) {
+func writePrometheusStats(writer http.ResponseWriter, stats ServerStats, bulkheads []BulkheadStats) {
 	for _, metric := range statsPrometheusMetrics {
 		_, _ = fmt.Fprintf(writer, "# HELP %s %s\n", metric.name, metric.help)
 		_, _ = fmt.Fprintf(writer, "# TYPE %s %s\n", metric.name, metric.typ)
 		_, _ = fmt.Fprintf(writer, "%s{transport=\"tcp\"} %s\n", metric.name, metric.value(stats.TCP))
 		_, _ = fmt.Fprintf(writer, "%s{transport=\"websocket\"} %s\n", metric.name, metric.value(stats.WebSocket))
 	}
+	_, _ = fmt.Fprint(writer, "# HELP ramix_workers Number of Ramix worker goroutines currently running.\n")
+	_, _ = fmt.Fprint(writer, "# TYPE ramix_workers gauge\n")
+	_, _ = fmt.Fprintf(writer, "ramix_workers %d\n", stats.Workers)
+	_, _ = fmt.Fprint(writer, "# HELP ramix_priority_queued_tasks Approximate number of Ramix request tasks waiting in worker queues by route priority.\n")
+	_, _ = fmt.Fprint(writer, "# TYPE ramix_priority_queued_tasks gauge\n")
+	_, _ = fmt.Fprintf(writer, "ramix_priority_queued_tasks{priority=\"high\"} %d\n", stats.QueuedTasksByPriority.High)
+	_, _ = fmt.Fprintf(writer, "ramix_priority_queued_tasks{priority=\"normal\"} %d\n", stats.QueuedTasksByPriority.Normal)
+	_, _ = fmt.Fprintf(writer, "ramix_priority_queued_tasks{priority=\"low\"} %d\n", stats.QueuedTasksByPriority.Low)
+	if len(bulkheads) == 0 {
+		return
+	}
+	for _, metric := range []struct {
+		name  string
+		help  string
+		typ   string
+		value func(BulkheadStats) uint64
+	}{
+		{"ramix_bulkhead_active", "Number of Ramix messages currently running under a bulkhead.", "gauge", func(stats BulkheadStats) uint64 { return stats.Active }},
+		{"ramix_bulkhead_queued", "Number of Ramix messages currently waiting for a bulkhead.", "gauge", func(stats BulkheadStats) uint64 { return stats.Queued }},
+		{"ramix_bulkhead_rejected_total", "Lifetime-cumulative number of Ramix messages rejected by a bulkhead.", "counter", func(stats BulkheadStats) uint64 { return stats.Rejected }},
+	} {
+		_, _ = fmt.Fprintf(writer, "# HELP %s %s\n", metric.name, metric.help)
+		_, _ = fmt.Fprintf(writer, "# TYPE %s %s\n", metric.name, metric.typ)
+		for _, bulkhead := range bulkheads {
+			_, _ = fmt.Fprintf(writer, "%s{bulkhead=\"%s\"} %d\n", metric.name, prometheusLabelEscaper.Replace(bulkhead.Name), metric.value(bulkhead))
+		}
+	}
 }
 
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
 func prometheusUint64(get func(TransportStats) uint64) func(TransportStats) string {
 	return func(stats TransportStats) string {
 		return strconv.FormatUint(get(stats), 10)
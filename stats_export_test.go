@@ -63,14 +63,28 @@ func TestStatsJSONHandlerExportsSnapshot(t *testing.T) {
 	}
 	assertContentType(t, recorder, "application/json; charset=utf-8")
 
-	var body map[string]map[string]uint64
+	var body struct {
+		Total     map[string]uint64 `json:"total"`
+		TCP       map[string]uint64 `json:"tcp"`
+		WebSocket map[string]uint64 `json:"websocket"`
+		Workers   uint64            `json:"workers"`
+
+		QueuedTasksByPriority map[string]uint64 `json:"queued_tasks_by_priority"`
+	}
 	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
 		t.Fatalf("json.Unmarshal() error = %v; body = %s", err, recorder.Body.String())
 	}
 
-	assertJSONTransportStats(t, body["tcp"], wantTCPExport())
-	assertJSONTransportStats(t, body["websocket"], wantWebSocketExport())
-	assertJSONTransportStats(t, body["total"], wantTotalExport())
+	assertJSONTransportStats(t, body.TCP, wantTCPExport())
+	assertJSONTransportStats(t, body.WebSocket, wantWebSocketExport())
+	assertJSONTransportStats(t, body.Total, wantTotalExport())
+	if body.Workers != 3 {
+		t.Fatalf("workers = %d, want 3", body.Workers)
+	}
+	wantPriorities := map[string]uint64{"high": 1, "normal": 0, "low": 2}
+	if !reflect.DeepEqual(body.QueuedTasksByPriority, wantPriorities) {
+		t.Fatalf("queued_tasks_by_priority = %v, want %v", body.QueuedTasksByPriority, wantPriorities)
+	}
 }
 
 func TestStatsJSONHandlerHeadOmitsBody(t *testing.T) {
@@ -117,6 +131,13 @@ func TestStatsPrometheusHandlerExportsMetrics(t *testing.T) {
 	assertPrometheusContains(t, body, `ramix_completed_requests_total{transport="websocket"} 1`)
 	assertPrometheusContains(t, body, `ramix_request_duration_seconds_total{transport="tcp"} 1.5`)
 	assertPrometheusContains(t, body, `ramix_request_duration_seconds_max{transport="websocket"} 0.25`)
+	assertPrometheusContains(t, body, `ramix_rejected_origins_total{transport="websocket"} 1`)
+	assertPrometheusContains(t, body, "# TYPE ramix_workers gauge")
+	assertPrometheusContains(t, body, "\nramix_workers 3\n")
+	assertPrometheusContains(t, body, "# TYPE ramix_priority_queued_tasks gauge")
+	assertPrometheusContains(t, body, `ramix_priority_queued_tasks{priority="high"} 1`)
+	assertPrometheusContains(t, body, `ramix_priority_queued_tasks{priority="normal"} 0`)
+	assertPrometheusContains(t, body, `ramix_priority_queued_tasks{priority="low"} 2`)
 	if strings.Contains(body, `transport="total"`) {
 		t.Fatalf("Prometheus output contains transport total series:\n%s", body)
 	}
@@ -202,6 +223,11 @@ func seedStatsExportMetrics(server *Server) {
 	server.metrics.messageReceived(TransportWebSocket, 32)
 	server.metrics.messageSent(TransportWebSocket, 16)
 	server.metrics.requestCompleted(TransportWebSocket, 250*time.Millisecond)
+	server.metrics.originRejected(TransportWebSocket)
+	server.metrics.workers.Store(3)
+	server.metrics.priorityQueued(PriorityHigh)
+	server.metrics.priorityQueued(PriorityLow)
+	server.metrics.priorityQueued(PriorityLow)
 }
 
 func assertContentType(t *testing.T, recorder *httptest.ResponseRecorder, want string) {
@@ -258,6 +284,24 @@ func wantPrometheusFamilies() []string {
 		"ramix_completed_requests_total",
 		"ramix_request_duration_seconds_total",
 		"ramix_request_duration_seconds_max",
+		"ramix_rejected_origins_total",
+		"ramix_compressed_raw_bytes_total",
+		"ramix_compressed_wire_bytes_total",
+		"ramix_rejected_ip_connections_total",
+		"ramix_rejected_accept_rate_total",
+		"ramix_rejected_denied_ips_total",
+		"ramix_rejected_server_full_total",
+		"ramix_throttled_messages_total",
+		"ramix_evicted_tasks_total",
+		"ramix_blocked_tasks_total",
+		"ramix_expired_tasks_total",
+		"ramix_shed_tasks_total",
+		"ramix_timed_out_requests_total",
+		"ramix_dropped_sends_total",
+		"ramix_slow_consumer_disconnects_total",
+		"ramix_write_batches_total",
+		"ramix_workers",
+		"ramix_priority_queued_tasks",
 	}
 }
 
@@ -274,6 +318,22 @@ func wantTCPExport() map[string]uint64 {
 		"completed_requests":          1,
 		"total_request_duration_ns":   1500 * uint64(time.Millisecond),
 		"maximum_request_duration_ns": 1500 * uint64(time.Millisecond),
+		"rejected_origins":            0,
+		"compressed_raw_bytes":        0,
+		"compressed_wire_bytes":       0,
+		"rejected_ip_connections":     0,
+		"rejected_accept_rate":        0,
+		"rejected_denied_ips":         0,
+		"rejected_server_full":        0,
+		"throttled_messages":          0,
+		"evicted_tasks":               0,
+		"blocked_tasks":               0,
+		"expired_tasks":               0,
+		"shed_tasks":                  0,
+		"timed_out_requests":          0,
+		"dropped_sends":               0,
+		"slow_consumer_disconnects":   0,
+		"write_batches":               0,
 	}
 }
 
@@ -290,6 +350,22 @@ func wantWebSocketExport() map[string]uint64 {
 		"completed_requests":          1,
 		"total_request_duration_ns":   250 * uint64(time.Millisecond),
 		"maximum_request_duration_ns": 250 * uint64(time.Millisecond),
+		"rejected_origins":            1,
+		"compressed_raw_bytes":        0,
+		"compressed_wire_bytes":       0,
+		"rejected_ip_connections":     0,
+		"rejected_accept_rate":        0,
+		"rejected_denied_ips":         0,
+		"rejected_server_full":        0,
+		"throttled_messages":          0,
+		"evicted_tasks":               0,
+		"blocked_tasks":               0,
+		"expired_tasks":               0,
+		"shed_tasks":                  0,
+		"timed_out_requests":          0,
+		"dropped_sends":               0,
+		"slow_consumer_disconnects":   0,
+		"write_batches":               0,
 	}
 }
 
@@ -306,5 +382,21 @@ func wantTotalExport() map[string]uint64 {
 		"completed_requests":          2,
 		"total_request_duration_ns":   1750 * uint64(time.Millisecond),
 		"maximum_request_duration_ns": 1500 * uint64(time.Millisecond),
+		"rejected_origins":            1,
+		"compressed_raw_bytes":        0,
+		"compressed_wire_bytes":       0,
+		"rejected_ip_connections":     0,
+		"rejected_accept_rate":        0,
+		"rejected_denied_ips":         0,
+		"rejected_server_full":        0,
+		"throttled_messages":          0,
+		"evicted_tasks":               0,
+		"blocked_tasks":               0,
+		"expired_tasks":               0,
+		"shed_tasks":                  0,
+		"timed_out_requests":          0,
+		"dropped_sends":               0,
+		"slow_consumer_disconnects":   0,
+		"write_batches":               0,
 	}
 }
@@ -111,8 +111,8 @@ func TestServerStatsRemainReadableAcrossRunAndShutdown(t *testing.T) {
 		}
 	}
 
-	if got := server.Stats(); got != (ServerStats{}) {
-		t.Fatalf("Stats() while running = %+v, want zero value", got)
+	if got, want := server.Stats(), (ServerStats{Workers: uint64(server.WorkerCount)}); got != want {
+		t.Fatalf("Stats() while running = %+v, want %+v", got, want)
 	}
 	server.metrics.messageReceived(TransportTCP, 37)
 	runningStats := server.Stats()
@@ -144,8 +144,10 @@ func TestServerStatsRemainReadableAcrossRunAndShutdown(t *testing.T) {
 		t.Fatalf("timed out waiting for Run(): %v", shutdownContext.Err())
 	}
 
-	if got := server.Stats(); got != runningStats {
-		t.Fatalf("Stats() after shutdown = %+v, want preserved snapshot %+v", got, runningStats)
+	wantShutdown := runningStats
+	wantShutdown.Workers = 0
+	if got := server.Stats(); got != wantShutdown {
+		t.Fatalf("Stats() after shutdown = %+v, want preserved snapshot %+v", got, wantShutdown)
 	}
 }
 
@@ -408,6 +410,134 @@ func TestServerMetricsTotalSaturatesEveryAdditiveField(t *testing.T) {
 			},
 			get: func(stats TransportStats) uint64 { return stats.CompletedRequests },
 		},
+		{
+			name: "RejectedOrigins",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.rejectedOrigins.Store(math.MaxUint64 - 1)
+				metrics.webSocket.rejectedOrigins.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.RejectedOrigins },
+		},
+		{
+			name: "CompressedRawBytes",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.compressedRawBytes.Store(math.MaxUint64 - 1)
+				metrics.webSocket.compressedRawBytes.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.CompressedRawBytes },
+		},
+		{
+			name: "CompressedWireBytes",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.compressedWireBytes.Store(math.MaxUint64 - 1)
+				metrics.webSocket.compressedWireBytes.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.CompressedWireBytes },
+		},
+		{
+			name: "RejectedIPConnections",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.rejectedIPConnections.Store(math.MaxUint64 - 1)
+				metrics.webSocket.rejectedIPConnections.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.RejectedIPConnections },
+		},
+		{
+			name: "RejectedAcceptRate",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.rejectedAcceptRate.Store(math.MaxUint64 - 1)
+				metrics.webSocket.rejectedAcceptRate.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.RejectedAcceptRate },
+		},
+		{
+			name: "RejectedDeniedIPs",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.rejectedDeniedIPs.Store(math.MaxUint64 - 1)
+				metrics.webSocket.rejectedDeniedIPs.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.RejectedDeniedIPs },
+		},
+		{
+			name: "RejectedServerFull",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.rejectedServerFull.Store(math.MaxUint64 - 1)
+				metrics.webSocket.rejectedServerFull.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.RejectedServerFull },
+		},
+		{
+			name: "ThrottledMessages",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.throttledMessages.Store(math.MaxUint64 - 1)
+				metrics.webSocket.throttledMessages.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.ThrottledMessages },
+		},
+		{
+			name: "EvictedTasks",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.evictedTasks.Store(math.MaxUint64 - 1)
+				metrics.webSocket.evictedTasks.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.EvictedTasks },
+		},
+		{
+			name: "BlockedTasks",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.blockedTasks.Store(math.MaxUint64 - 1)
+				metrics.webSocket.blockedTasks.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.BlockedTasks },
+		},
+		{
+			name: "ExpiredTasks",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.expiredTasks.Store(math.MaxUint64 - 1)
+				metrics.webSocket.expiredTasks.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.ExpiredTasks },
+		},
+		{
+			name: "ShedTasks",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.shedTasks.Store(math.MaxUint64 - 1)
+				metrics.webSocket.shedTasks.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.ShedTasks },
+		},
+		{
+			name: "TimedOutRequests",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.timedOutRequests.Store(math.MaxUint64 - 1)
+				metrics.webSocket.timedOutRequests.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.TimedOutRequests },
+		},
+		{
+			name: "DroppedSends",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.droppedSends.Store(math.MaxUint64 - 1)
+				metrics.webSocket.droppedSends.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.DroppedSends },
+		},
+		{
+			name: "SlowConsumerDisconnects",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.slowConsumerDisconnects.Store(math.MaxUint64 - 1)
+				metrics.webSocket.slowConsumerDisconnects.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.SlowConsumerDisconnects },
+		},
+		{
+			name: "WriteBatches",
+			set: func(metrics *serverMetrics) {
+				metrics.tcp.writeBatches.Store(math.MaxUint64 - 1)
+				metrics.webSocket.writeBatches.Store(2)
+			},
+			get: func(stats TransportStats) uint64 { return stats.WriteBatches },
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,65 @@
+package ramix
+
+type taskDeque struct {
+	tasks []*Context
+	head  int
+	count int
+}
+
+func newTaskDeque(capacity int) taskDeque {
+	return taskDeque{tasks: make([]*Context, capacity)}
+}
+
+func (d *taskDeque) len() int {
+	return d.count
+}
+
+func (d *taskDeque) front() *Context {
+	if d.count == 0 {
+		return nil
+	}
+	return d.tasks[d.head]
+}
+
+func (d *taskDeque) pushBack(task *Context) {
+	if d.count == len(d.tasks) {
+		d.grow()
+	}
+	d.tasks[(d.head+d.count)%len(d.tasks)] = task
+	d.count++
+}
+
+func (d *taskDeque) popFront() *Context {
+	if d.count == 0 {
+		return nil
+	}
+	task := d.tasks[d.head]
+	d.tasks[d.head] = nil
+	d.head = (d.head + 1) % len(d.tasks)
+	d.count--
+	return task
+}
+
+func (d *taskDeque) popBack() *Context {
+	if d.count == 0 {
+		return nil
+	}
+	index := (d.head + d.count - 1) % len(d.tasks)
+	task := d.tasks[index]
+	d.tasks[index] = nil
+	d.count--
+	return task
+}
+
+func (d *taskDeque) evict() *Context {
+	return d.popFront()
+}
+
+func (d *taskDeque) grow() {
+	tasks := make([]*Context, 2*len(d.tasks)+1)
+	for i := 0; i < d.count; i++ {
+		tasks[i] = d.tasks[(d.head+i)%len(d.tasks)]
+	}
+	d.tasks = tasks
+	d.head = 0
+}
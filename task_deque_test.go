@@ -0,0 +1,58 @@
+package ramix
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTaskDequeWrapsAround(t *testing.T) {
+	deque := newTaskDeque(3)
+	tasks := make([]*Context, 5)
+	for i := range tasks {
+		tasks[i] = newContext(context.Background(), nil, nil)
+	}
+
+	deque.pushBack(tasks[0])
+	deque.pushBack(tasks[1])
+	deque.pushBack(tasks[2])
+	if got := deque.popFront(); got != tasks[0] {
+		t.Fatal("popFront() did not return the oldest task")
+	}
+	deque.pushBack(tasks[3])
+	if got := deque.popBack(); got != tasks[3] {
+		t.Fatal("popBack() did not return the newest task")
+	}
+	deque.pushBack(tasks[4])
+
+	for _, want := range []*Context{tasks[1], tasks[2], tasks[4]} {
+		if got := deque.popFront(); got != want {
+			t.Fatal("popFront() returned tasks out of order")
+		}
+	}
+	if deque.len() != 0 || deque.popFront() != nil || deque.popBack() != nil {
+		t.Fatal("Expected an empty deque")
+	}
+}
+
+func TestTaskDequeGrowsWhenFull(t *testing.T) {
+	deque := newTaskDeque(2)
+	tasks := make([]*Context, 4)
+	for i := range tasks {
+		tasks[i] = newContext(context.Background(), nil, nil)
+	}
+
+	deque.pushBack(tasks[0])
+	deque.pushBack(tasks[1])
+	deque.popFront()
+	deque.pushBack(tasks[2])
+	deque.pushBack(tasks[3])
+
+	if got := deque.len(); got != 3 {
+		t.Fatalf("len() = %d, want 3", got)
+	}
+	for _, want := range tasks[1:] {
+		if got := deque.popFront(); got != want {
+			t.Fatal("popFront() returned tasks out of order after growing")
+		}
+	}
+}
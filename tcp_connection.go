@@ -1,6 +1,8 @@
 package ramix
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -69,6 +71,10 @@ func (c *TCPConnection) processInput(input []byte) error {
 		if err != nil {
 			return err
 		}
+		if c.server.TCPPing && message.Event == c.server.TCPPingEvent {
+			c.receivePong(message.Body)
+			continue
+		}
 		c.server.metrics.messageReceived(c.metricTransport, uint64(len(message.Body)))
 
 		err = c.server.handleRequest(c, newRequest(message))
@@ -76,6 +82,8 @@ func (c *TCPConnection) processInput(input []byte) error {
 		case err == nil:
 		case errors.Is(err, ErrServerStopping):
 			return ErrServerStopping
+		case errors.Is(err, ErrConnectionClosed):
+			return nil
 		case errors.Is(err, ErrWorkerQueueFull):
 			c.server.reportConnectionError(c, OperationTask, err)
 			c.requestClose(OperationTask, err)
@@ -91,9 +99,36 @@ func (c *TCPConnection) processInput(input []byte) error {
 }
 
 func (c *TCPConnection) open() {
+	if c.server.TCPPing {
+		c.ping = c.sendPingMessage
+	}
 	c.start(c, c.reader)
 }
 
+func (c *TCPConnection) sendPingMessage(payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.server.HeartbeatInterval)
+	defer cancel()
+	err := c.send(c.server.TCPPingEvent, payload, func(data []byte) error {
+		return c.enqueueControl(ctx, data)
+	})
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrConnectionClosed) {
+		return nil
+	}
+	return err
+}
+
+func writeBuffers(conn net.Conn, messages [][]byte) error {
+	if proxied, ok := conn.(*proxiedConn); ok {
+		conn = proxied.Conn
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		buffers := append(net.Buffers(nil), messages...)
+		_, err := buffers.WriteTo(tcp)
+		return err
+	}
+	return writeFull(conn, bytes.Join(messages, nil))
+}
+
 func writeFull(writer io.Writer, data []byte) error {
 	for len(data) > 0 {
 		written, err := writer.Write(data)
@@ -4,6 +4,8 @@ import (
 	"net"
 )
 
+const maxPendingTCPSetups = 1024
+
 func (s *Server) serveTCP(listener net.Listener) error {
 	for {
 		socket, err := listener.Accept()
@@ -13,15 +15,79 @@ func (s *Server) serveTCP(listener net.Listener) error {
 			}
 			return err
 		}
-		if s.connectionManager.connectionsCount() >= s.MaxConnectionsCount {
+		if !s.beginConnectionSetup() {
 			_ = socket.Close()
 			continue
 		}
-		if !s.beginConnectionSetup() {
-			_ = socket.Close()
+		proxied := s.ProxyProtocol && s.trustsProxyProtocol(socket)
+		var release func()
+		if !proxied {
+			if release, err = s.admitClient(TransportTCP, socket.RemoteAddr()); err != nil {
+				_ = socket.Close()
+				s.finishConnectionSetup()
+				continue
+			}
+		}
+		if s.serverFull() {
+			if release != nil {
+				release()
+			}
+			s.rejectFullTCPConnection(socket, proxied)
+			continue
+		}
+		if proxied || s.tcpTLS() {
+			s.pendingTCPSetups.Add(1)
+			go func(socket net.Conn) {
+				defer s.finishConnectionSetup()
+				defer s.pendingTCPSetups.Add(-1)
+				s.setupPendingTCPConnection(socket, proxied, release)
+			}(socket)
 			continue
 		}
-		s.openTCPConnection(socket, s.nextConnectionID())
+		s.openTCPConnection(socket, s.nextConnectionID(), release)
 		s.finishConnectionSetup()
 	}
 }
+
+func (s *Server) setupPendingTCPConnection(socket net.Conn, proxied bool, release func()) {
+	if !s.trackPendingSocket(socket) {
+		if release != nil {
+			release()
+		}
+		_ = socket.Close()
+		return
+	}
+	connection, release, err := s.prepareTCPConnection(socket, proxied, release)
+	s.untrackPendingSocket(socket)
+	if err != nil {
+		debug("TCP connection from %s rejected: %v", socket.RemoteAddr(), err)
+		_ = socket.Close()
+		return
+	}
+	s.openTCPConnection(connection, s.nextConnectionID(), release)
+}
+
+func (s *Server) prepareTCPConnection(socket net.Conn, proxied bool, release func()) (net.Conn, func(), error) {
+	if proxied {
+		var err error
+		if socket, err = s.readProxyHeader(socket); err != nil {
+			return nil, nil, err
+		}
+		if release, err = s.admitClient(TransportTCP, socket.RemoteAddr()); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !s.tcpTLS() {
+		return socket, release, nil
+	}
+	connection, err := s.handshakeTLS(socket)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return connection, release, nil
+}
+
+func (s *Server) tcpTLS() bool {
+	return s.tlsConfig != nil && s.usesTLS(TransportTCP)
+}
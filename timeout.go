@@ -0,0 +1,85 @@
+package ramix
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+type TimeoutConfig struct {
+	Timeout time.Duration
+	Event   uint32
+	Body    []byte
+}
+
+var DefaultTimeoutConfig = TimeoutConfig{
+	Event: 408,
+	Body:  []byte("Request Timeout"),
+}
+
+func Timeout(timeout time.Duration) Handler {
+	config := DefaultTimeoutConfig
+	config.Timeout = timeout
+	return TimeoutWithConfig(config)
+}
+
+func TimeoutWithConfig(config TimeoutConfig) Handler {
+	if config.Timeout <= 0 {
+		panic("timeout must be positive")
+	}
+	return timeoutHandler(config)
+}
+
+func timeoutHandler(config TimeoutConfig) Handler {
+	return func(c *Context) {
+		previousDeadline := c.replyDeadline.Load()
+		if deadline := time.Now().Add(config.Timeout).UnixNano(); previousDeadline == 0 || deadline < previousDeadline {
+			c.replyDeadline.Store(deadline)
+		}
+		parent := c.Context
+		ctx, cancel := context.WithTimeout(parent, config.Timeout)
+		defer cancel()
+		c.Context = ctx
+
+		var finished atomic.Bool
+		timedOut := func() {
+			if !finished.CompareAndSwap(false, true) {
+				return
+			}
+			c.requestTimedOut()
+			_ = c.Connection.Send(parent, config.Event, config.Body)
+		}
+		timer := time.AfterFunc(config.Timeout, timedOut)
+		defer func() {
+			timer.Stop()
+			c.Context = parent
+			if ctx.Err() != nil && parent.Err() == nil {
+				timedOut()
+			} else if finished.CompareAndSwap(false, true) {
+				c.replyDeadline.Store(previousDeadline)
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+func WithTimeout(timeout time.Duration) RouteOption {
+	config := DefaultTimeoutConfig
+	config.Timeout = timeout
+	return WithTimeoutConfig(config)
+}
+
+func WithTimeoutConfig(config TimeoutConfig) RouteOption {
+	return func(settings *routeSettings) {
+		settings.timeout = &config
+	}
+}
+
+func validateTimeout(config *TimeoutConfig) error {
+	if config != nil && config.Timeout <= 0 {
+		return fmt.Errorf("%w: route timeout must be positive: %s", ErrInvalidConfiguration, config.Timeout)
+	}
+	return nil
+}
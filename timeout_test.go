@@ -0,0 +1,148 @@
+package ramix
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type sentMessage struct {
+	event uint32
+	body  string
+}
+
+type recordingConnection struct {
+	mu   sync.Mutex
+	sent []sentMessage
+}
+
+func (c *recordingConnection) ID() uint64              { return 1 }
+func (c *recordingConnection) RemoteAddress() net.Addr { return nil }
+func (c *recordingConnection) Send(_ context.Context, event uint32, body []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, sentMessage{event: event, body: string(body)})
+	return nil
+}
+
+func (c *recordingConnection) messages() []sentMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]sentMessage(nil), c.sent...)
+}
+
+func TestTimeoutRepliesWhenDeadlinePasses(t *testing.T) {
+	var metrics serverMetrics
+	connection := &recordingConnection{}
+	c := newContext(context.Background(), connection, nil)
+	c.metrics = &metrics
+	c.metricTransport = TransportTCP
+
+	var handlerErr error
+	c.handlers = []Handler{Timeout(10 * time.Millisecond), func(ctx *Context) {
+		<-ctx.Done()
+		handlerErr = ctx.Err()
+	}}
+	c.Next()
+
+	if !errors.Is(handlerErr, context.DeadlineExceeded) {
+		t.Fatalf("handler ctx.Err() = %v, want %v", handlerErr, context.DeadlineExceeded)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(connection.messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	want := []sentMessage{{event: 408, body: "Request Timeout"}}
+	if got := connection.messages(); len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("sent = %v, want %v", got, want)
+	}
+	if got := metrics.snapshot().TCP.TimedOutRequests; got != 1 {
+		t.Fatalf("TimedOutRequests = %d, want 1", got)
+	}
+}
+
+func TestTimeoutDoesNotReplyToFastHandler(t *testing.T) {
+	var metrics serverMetrics
+	connection := &recordingConnection{}
+	c := newContext(context.Background(), connection, nil)
+	c.metrics = &metrics
+	c.metricTransport = TransportTCP
+
+	var hasDeadline bool
+	c.handlers = []Handler{TimeoutWithConfig(TimeoutConfig{Timeout: 20 * time.Millisecond, Event: 504}), func(ctx *Context) {
+		_, hasDeadline = ctx.Deadline()
+	}}
+	c.Next()
+
+	if !hasDeadline {
+		t.Fatal("handler context has no deadline")
+	}
+	if got := connection.messages(); len(got) != 0 {
+		t.Fatalf("sent = %v, want none", got)
+	}
+	if c.repliesSuppressed() {
+		t.Fatal("replies after a fast handler are suppressed")
+	}
+	if got := metrics.snapshot().TCP.TimedOutRequests; got != 0 {
+		t.Fatalf("TimedOutRequests = %d, want 0", got)
+	}
+}
+
+func TestTimeoutRestoresContextForEarlierMiddleware(t *testing.T) {
+	c := newContext(context.Background(), &recordingConnection{}, nil)
+	parent := c.Context
+
+	var afterNext error
+	c.handlers = []Handler{func(ctx *Context) {
+		ctx.Next()
+		afterNext = ctx.Err()
+		if ctx.Context != parent {
+			t.Error("context after Timeout returned is not the request context")
+		}
+	}, Timeout(time.Hour), func(*Context) {}}
+	c.Next()
+
+	if afterNext != nil {
+		t.Fatalf("context error after Timeout returned = %v, want nil", afterNext)
+	}
+}
+
+func TestTimeoutWithConfigPanicsOnInvalidTimeout(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("TimeoutWithConfig() did not panic")
+		}
+	}()
+	TimeoutWithConfig(TimeoutConfig{})
+}
+
+func TestRouteGroup_RegisterRouteWithTimeout(t *testing.T) {
+	rg := newGroup(newRouter())
+	if err := rg.Use(func(ctx *Context) { ctx.Next() }); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	if err := rg.RegisterRoute(1, func(*Context) {}, WithTimeout(time.Second)); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	if err := rg.RegisterRoute(2, func(*Context) {}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	if err := rg.RegisterRoute(3, func(*Context) {}, WithTimeout(0)); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Fatalf("RegisterRoute() with zero timeout error = %v, want ErrInvalidConfiguration", err)
+	}
+
+	routes := rg.router.freeze()
+	if got := len(routes[1]); got != 3 {
+		t.Errorf("Expected timeout middleware before the group middleware, got %d handlers", got)
+	}
+	if got := len(routes[2]); got != 2 {
+		t.Errorf("Expected route without timeout to keep 2 handlers, got %d", got)
+	}
+	if _, ok := routes[3]; ok {
+		t.Error("Expected route with invalid timeout not to be registered")
+	}
+}
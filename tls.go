@@ -0,0 +1,94 @@
+package ramix
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+)
+
+func validateTLSOptions(opts ServerOptions) error {
+	enabled := opts.CertFile != "" || opts.TLSConfig != nil
+	if opts.TLSConfig != nil && opts.CertFile == "" && len(opts.TLSConfig.Certificates) == 0 &&
+		opts.TLSConfig.GetCertificate == nil && opts.TLSConfig.GetConfigForClient == nil {
+		return fmt.Errorf("%w: tls config must provide a certificate", ErrInvalidConfiguration)
+	}
+	if len(opts.TLSTransports) > 0 && !enabled {
+		return fmt.Errorf("%w: tls transports require a tls certificate", ErrInvalidConfiguration)
+	}
+	for _, transport := range opts.TLSTransports {
+		if !opts.HasTransport(transport) {
+			return fmt.Errorf("%w: tls transport %q is not enabled", ErrInvalidConfiguration, transport.String())
+		}
+	}
+	if opts.CertificateReloadInterval < 0 {
+		return fmt.Errorf("%w: certificate reload interval must not be negative: %s", ErrInvalidConfiguration, opts.CertificateReloadInterval)
+	}
+	if opts.CertificateReloadInterval > 0 && opts.CertFile == "" {
+		return fmt.Errorf("%w: certificate reload interval requires a cert file", ErrInvalidConfiguration)
+	}
+	if enabled && opts.usesTLS(TransportTCP) && opts.TLSHandshakeTimeout <= 0 {
+		return fmt.Errorf("%w: tls handshake timeout must be positive: %s", ErrInvalidConfiguration, opts.TLSHandshakeTimeout)
+	}
+	return nil
+}
+
+func (o ServerOptions) usesTLS(transport Transport) bool {
+	if !o.HasTransport(transport) {
+		return false
+	}
+	if len(o.TLSTransports) == 0 {
+		return true
+	}
+	for _, enabled := range o.TLSTransports {
+		if enabled == transport {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) buildTLSConfig() (*tls.Config, error) {
+	if s.CertFile == "" && s.TLSConfig == nil {
+		return nil, nil
+	}
+	config := s.TLSConfig.Clone()
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if s.CertFile != "" {
+		reloader, err := newCertificateReloader(s.CertFile, s.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.GetCertificate = reloader.getCertificate(config.GetCertificate, config.Certificates)
+		config.Certificates = nil
+		s.stateMu.Lock()
+		s.certificates = reloader
+		s.stateMu.Unlock()
+	}
+	return config, nil
+}
+
+func (s *Server) handshakeTLS(socket net.Conn) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.TLSHandshakeTimeout)
+	defer cancel()
+	connection := tls.Server(socket, s.tlsConfig)
+	if err := connection.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+func verifiedPeerCertificate(socket net.Conn) *x509.Certificate {
+	connection, ok := socket.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := connection.ConnectionState()
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
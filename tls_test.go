@@ -0,0 +1,145 @@
+package ramix
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type testCertificateAuthority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+func newTestCertificateAuthority(t *testing.T) *testCertificateAuthority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ramix test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return &testCertificateAuthority{certificate: certificate, key: key}
+}
+
+func (ca *testCertificateAuthority) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.certificate)
+	return pool
+}
+
+func (ca *testCertificateAuthority) issueClient(t *testing.T, commonName string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestUsesTLS(t *testing.T) {
+	opts := defaultServerOptions()
+	if !opts.usesTLS(TransportTCP) || !opts.usesTLS(TransportWebSocket) {
+		t.Fatal("usesTLS() = false with default TLS transports, want true")
+	}
+	WithTLSTransports(TransportTCP)(&opts)
+	if !opts.usesTLS(TransportTCP) || opts.usesTLS(TransportWebSocket) {
+		t.Fatal("usesTLS() did not restrict TLS to TCP")
+	}
+	WithTransports(TransportWebSocket)(&opts)
+	if opts.usesTLS(TransportTCP) {
+		t.Fatal("usesTLS(TransportTCP) = true for a disabled transport")
+	}
+}
+
+func TestWithTLSConfigCopiesConfig(t *testing.T) {
+	config := &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}
+	opts := defaultServerOptions()
+	WithTLSConfig(config)(&opts)
+	config.ClientAuth = tls.NoClientCert
+	if opts.TLSConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("TLSConfig.ClientAuth = %v, want %v", opts.TLSConfig.ClientAuth, tls.RequireAndVerifyClientCert)
+	}
+}
+
+func TestBuildTLSConfigAddsKeyPair(t *testing.T) {
+	server, err := NewServer(
+		WithTLSConfig(&tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, MinVersion: tls.VersionTLS13}),
+		WithCertFile("examples/tls/public_certificate.pem"),
+		WithPrivateKeyFile("examples/tls/private_key.pem"),
+	)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	config, err := server.buildTLSConfig()
+	if err != nil {
+		t.Fatalf("buildTLSConfig() error = %v", err)
+	}
+	if config.ClientAuth != tls.VerifyClientCertIfGiven || config.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("buildTLSConfig() = client auth %v, min version %x", config.ClientAuth, config.MinVersion)
+	}
+	certificate, err := config.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil || certificate == nil {
+		t.Fatalf("GetCertificate() = (%v, %v), want the configured key pair", certificate, err)
+	}
+	if server.TLSConfig.Certificates != nil {
+		t.Fatal("buildTLSConfig() modified the configured TLSConfig")
+	}
+}
+
+func TestValidateTLSOptions(t *testing.T) {
+	tests := map[string][]ServerOption{
+		"config without certificate": {WithTLSConfig(&tls.Config{})},
+		"transports without tls":     {WithTLSTransports(TransportTCP)},
+		"disabled tls transport": {
+			WithTransports(TransportTCP),
+			WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{{}}}),
+			WithTLSTransports(TransportWebSocket),
+		},
+		"non-positive handshake timeout": {
+			WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{{}}}),
+			WithTLSHandshakeTimeout(0),
+		},
+	}
+	for name, options := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewServer(options...); !errors.Is(err, ErrInvalidConfiguration) {
+				t.Fatalf("NewServer() error = %v, want %v", err, ErrInvalidConfiguration)
+			}
+		})
+	}
+}
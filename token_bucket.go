@@ -0,0 +1,38 @@
+package ramix
+
+import "time"
+
+type tokenBucket struct {
+	tokens   float64
+	refilled time.Time
+}
+
+func newTokenBucket(burst float64, now time.Time) tokenBucket {
+	return tokenBucket{tokens: burst, refilled: now}
+}
+
+func (b *tokenBucket) refill(now time.Time, rate, burst float64) {
+	elapsed := now.Sub(b.refilled).Seconds()
+	b.refilled = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * rate
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+}
+
+func (b *tokenBucket) take(now time.Time, rate, burst float64) bool {
+	b.refill(now, rate, burst)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *tokenBucket) full(now time.Time, rate, burst float64) bool {
+	b.refill(now, rate, burst)
+	return b.tokens >= burst
+}
@@ -0,0 +1,77 @@
+package ramix
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+type countingListener struct {
+	net.Listener
+}
+
+type countingConn struct {
+	net.Conn
+	written atomic.Uint64
+}
+
+func (l countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn}, nil
+}
+
+func (c *countingConn) Write(data []byte) (int, error) {
+	written, err := c.Conn.Write(data)
+	if written > 0 {
+		c.written.Add(uint64(written))
+	}
+	return written, err
+}
+
+func writtenBytesCounter(conn net.Conn) *atomic.Uint64 {
+	for conn != nil {
+		if counting, ok := conn.(*countingConn); ok {
+			return &counting.written
+		}
+		wrapper, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			return nil
+		}
+		conn = wrapper.NetConn()
+	}
+	return nil
+}
+
+func offersCompression(header http.Header) bool {
+	for _, value := range header.Values("Sec-Websocket-Extensions") {
+		for _, extension := range strings.Split(value, ",") {
+			token, _, _ := strings.Cut(extension, ";")
+			if strings.TrimSpace(token) == "permessage-deflate" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *WebSocketConnection) writeDataMessage(messageType int, data []byte) error {
+	if !c.compression || len(data) < c.server.WebSocketCompressionThreshold {
+		c.socket.EnableWriteCompression(false)
+		return c.socket.WriteMessage(messageType, data)
+	}
+
+	c.socket.EnableWriteCompression(true)
+	if c.wireBytes == nil {
+		return c.socket.WriteMessage(messageType, data)
+	}
+	before := c.wireBytes.Load()
+	if err := c.socket.WriteMessage(messageType, data); err != nil {
+		return err
+	}
+	c.server.metrics.messageCompressed(c.metricTransport, uint64(len(data)), c.wireBytes.Load()-before)
+	return nil
+}
@@ -0,0 +1,59 @@
+package ramix
+
+import (
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestOffersCompression(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   bool
+	}{
+		{name: "missing header"},
+		{name: "plain offer", values: []string{"permessage-deflate"}, want: true},
+		{name: "offer with parameters", values: []string{"permessage-deflate; client_max_window_bits"}, want: true},
+		{name: "offer in list", values: []string{"x-webkit-deflate-frame, permessage-deflate"}, want: true},
+		{name: "offer in second header", values: []string{"x-custom", "permessage-deflate"}, want: true},
+		{name: "other extension", values: []string{"x-webkit-deflate-frame"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for _, value := range tt.values {
+				header.Add("Sec-WebSocket-Extensions", value)
+			}
+			if got := offersCompression(header); got != tt.want {
+				t.Fatalf("offersCompression(%q) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountingConnCountsWrittenBytes(t *testing.T) {
+	serverSide, clientSide := net.Pipe()
+	defer serverSide.Close()
+	defer clientSide.Close()
+	conn := &countingConn{Conn: serverSide}
+
+	go func() {
+		buffer := make([]byte, 16)
+		for {
+			if _, err := clientSide.Read(buffer); err != nil {
+				return
+			}
+		}
+	}()
+	if _, err := conn.Write([]byte("hello world")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got := writtenBytesCounter(conn); got == nil || got.Load() != 11 {
+		t.Fatalf("writtenBytesCounter() = %v, want 11 bytes", got)
+	}
+	if got := writtenBytesCounter(serverSide); got != nil {
+		t.Fatalf("writtenBytesCounter(uncounted) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,116 @@
+package ramix
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (s *Server) forwardedClientAddress(request *http.Request) net.Addr {
+	if len(s.trustedProxies) == 0 || !s.trustsForwardedPeer(request.RemoteAddr) {
+		return nil
+	}
+	if values := request.Header.Values("Forwarded"); len(values) > 0 {
+		return s.resolveForwardedChain(forwardedForValues(values))
+	}
+	if values := request.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		return s.resolveForwardedChain(splitHeaderList(values))
+	}
+	if value := request.Header.Get("X-Real-IP"); value != "" {
+		if address := parseForwardedAddress(value); address != nil {
+			return address
+		}
+	}
+	return nil
+}
+
+func (s *Server) trustsForwardedPeer(remoteAddress string) bool {
+	ip := remoteAddressIP(remoteAddress)
+	return ip != nil && containsIP(s.trustedProxies, ip)
+}
+
+func requestAddress(request *http.Request) net.Addr {
+	address, err := net.ResolveTCPAddr("tcp", request.RemoteAddr)
+	if err != nil {
+		return nil
+	}
+	return address
+}
+
+func remoteAddressIP(remoteAddress string) net.IP {
+	host, _, err := net.SplitHostPort(remoteAddress)
+	if err != nil {
+		host = remoteAddress
+	}
+	return net.ParseIP(host)
+}
+
+func (s *Server) resolveForwardedChain(hops []string) net.Addr {
+	var client *net.TCPAddr
+	for i := len(hops) - 1; i >= 0; i-- {
+		address := parseForwardedAddress(hops[i])
+		if address == nil {
+			break
+		}
+		client = address
+		if !containsIP(s.trustedProxies, address.IP) {
+			break
+		}
+	}
+	if client == nil {
+		return nil
+	}
+	return client
+}
+
+func forwardedForValues(values []string) []string {
+	var hops []string
+	for _, element := range splitHeaderList(values) {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if found && strings.EqualFold(key, "for") {
+				hops = append(hops, value)
+			}
+		}
+	}
+	return hops
+}
+
+func splitHeaderList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+func parseForwardedAddress(value string) *net.TCPAddr {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if ip := net.ParseIP(value); ip != nil {
+		return &net.TCPAddr{IP: ip}
+	}
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		if ip := net.ParseIP(value[1 : len(value)-1]); ip != nil {
+			return &net.TCPAddr{IP: ip}
+		}
+		return nil
+	}
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	number, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil
+	}
+	return &net.TCPAddr{IP: ip, Port: int(number)}
+}
@@ -0,0 +1,61 @@
+package ramix
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestForwardedClientAddress(t *testing.T) {
+	trusted, err := parseTrustedCIDRs([]string{"10.0.0.0/8", "2001:db8:ffff::/48"})
+	if err != nil {
+		t.Fatalf("parseTrustedCIDRs() error = %v", err)
+	}
+	server := &Server{trustedProxies: trusted}
+
+	tests := []struct {
+		name    string
+		peer    string
+		headers map[string][]string
+		want    string
+	}{
+		{name: "untrusted peer", peer: "192.0.2.1:5000", headers: map[string][]string{"X-Forwarded-For": {"203.0.113.7"}}},
+		{name: "no header", peer: "10.0.0.1:5000"},
+		{name: "x-forwarded-for", peer: "10.0.0.1:5000", headers: map[string][]string{"X-Forwarded-For": {"203.0.113.7"}}, want: "203.0.113.7:0"},
+		{name: "x-forwarded-for skips trusted hops", peer: "10.0.0.1:5000", headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1, 203.0.113.7", "10.0.0.2"}}, want: "203.0.113.7:0"},
+		{name: "x-forwarded-for all trusted", peer: "10.0.0.1:5000", headers: map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, want: "10.0.0.3:0"},
+		{name: "x-forwarded-for stops at malformed hop", peer: "10.0.0.1:5000", headers: map[string][]string{"X-Forwarded-For": {"203.0.113.7, garbage, 10.0.0.2"}}, want: "10.0.0.2:0"},
+		{name: "forwarded", peer: "10.0.0.1:5000", headers: map[string][]string{"Forwarded": {`for="[2001:db8::7]:4242";proto=https, For=10.0.0.2`}}, want: "[2001:db8::7]:4242"},
+		{name: "forwarded takes precedence", peer: "10.0.0.1:5000", headers: map[string][]string{"Forwarded": {"for=203.0.113.7:4242"}, "X-Forwarded-For": {"198.51.100.1"}}, want: "203.0.113.7:4242"},
+		{name: "forwarded obfuscated", peer: "10.0.0.1:5000", headers: map[string][]string{"Forwarded": {"for=_hidden"}}},
+		{name: "x-real-ip", peer: "[2001:db8:ffff::1]:5000", headers: map[string][]string{"X-Real-IP": {"203.0.113.7"}}, want: "203.0.113.7:0"},
+		{name: "x-real-ip invalid", peer: "10.0.0.1:5000", headers: map[string][]string{"X-Real-IP": {"unknown"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/ws", nil)
+			request.RemoteAddr = tt.peer
+			for key, values := range tt.headers {
+				for _, value := range values {
+					request.Header.Add(key, value)
+				}
+			}
+			got := ""
+			if address := server.forwardedClientAddress(request); address != nil {
+				got = address.String()
+			}
+			if got != tt.want {
+				t.Fatalf("forwardedClientAddress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwardedClientAddressDisabledWithoutTrustedProxies(t *testing.T) {
+	request := httptest.NewRequest("GET", "/ws", nil)
+	request.RemoteAddr = "10.0.0.1:5000"
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if address := (&Server{}).forwardedClientAddress(request); address != nil {
+		t.Fatalf("forwardedClientAddress() = %v, want nil", address)
+	}
+}
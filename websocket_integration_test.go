@@ -5,8 +5,10 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -186,8 +188,10 @@ func TestIntegration_WebSocketStatisticsSnapshot(t *testing.T) {
 	if err := waitForIntegrationRun(t, run); err != nil {
 		t.Fatalf("Run() error = %v", err)
 	}
-	if got := server.Stats(); got != closedStats {
-		t.Fatalf("Stats() after WebSocket shutdown = %+v, want preserved snapshot %+v", got, closedStats)
+	wantShutdown := closedStats
+	wantShutdown.Workers = 0
+	if got := server.Stats(); got != wantShutdown {
+		t.Fatalf("Stats() after WebSocket shutdown = %+v, want preserved snapshot %+v", got, wantShutdown)
 	}
 }
 
@@ -432,3 +436,679 @@ func TestIntegration_WebSocketWorkerQueueSaturationIsIsolated(t *testing.T) {
 	}
 	assertIntegrationMessage(t, response, 117, "echo:healthy")
 }
+
+func dialWebSocketIntegrationRejected(t *testing.T, rawURL string, header http.Header) int {
+	t.Helper()
+	dialer := websocket.Dialer{HandshakeTimeout: integrationTimeout}
+	connection, response, err := dialer.Dial(rawURL, header)
+	if err == nil {
+		_ = connection.Close()
+		t.Fatal("websocket Dial() succeeded, want rejected upgrade")
+	}
+	if response == nil {
+		t.Fatalf("websocket Dial() error = %v, want HTTP rejection", err)
+	}
+	if response.Body != nil {
+		_ = response.Body.Close()
+	}
+	return response.StatusCode
+}
+
+func TestIntegration_WebSocketUpgradeHookAttachesAttributes(t *testing.T) {
+	server := newWebSocketIntegrationServer(t)
+	if err := server.OnWebSocketUpgrade(func(request *http.Request) (map[string]any, error) {
+		return map[string]any{
+			"user": request.Header.Get("Authorization"),
+			"room": request.URL.Query().Get("room"),
+		}, nil
+	}); err != nil {
+		t.Fatalf("OnWebSocketUpgrade() error = %v", err)
+	}
+	infos := make(chan ConnectionInfo, 1)
+	if err := server.RegisterRoute(16, func(ctx *Context) {
+		info, _ := ConnectionInfoOf(ctx.Connection)
+		infos <- info
+		_ = ctx.Connection.Send(ctx, 116, []byte(fmt.Sprint(info.Attributes["user"])))
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	rawURL := webSocketIntegrationURL(server, address.String(), false) + "?room=lobby"
+
+	dialer := websocket.Dialer{HandshakeTimeout: integrationTimeout}
+	client, response, err := dialer.Dial(rawURL, http.Header{"Authorization": []string{"alice"}})
+	if err != nil {
+		t.Fatalf("websocket Dial() error = %v", err)
+	}
+	if response.Body != nil {
+		_ = response.Body.Close()
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	setIntegrationDeadline(t, client.UnderlyingConn())
+
+	if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 16, "whoami")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	message, err := readWebSocketIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, message, 116, "alice")
+
+	info := <-infos
+	if info.Transport != TransportWebSocket {
+		t.Fatalf("Info().Transport = %v, want %v", info.Transport, TransportWebSocket)
+	}
+	if got := info.Attributes["room"]; got != "lobby" {
+		t.Fatalf("Info().Attributes[room] = %v, want lobby", got)
+	}
+	if info.Subprotocol != "" {
+		t.Fatalf("Info().Subprotocol = %q, want empty", info.Subprotocol)
+	}
+}
+
+func TestIntegration_WebSocketUpgradeHookRejectsUpgrade(t *testing.T) {
+	server := newWebSocketIntegrationServer(t)
+	if err := server.OnWebSocketUpgrade(func(request *http.Request) (map[string]any, error) {
+		switch request.URL.Query().Get("token") {
+		case "valid":
+			return nil, nil
+		case "":
+			return nil, RejectUpgrade(http.StatusUnauthorized, "missing token")
+		case "panic":
+			panic("upgrade hook failure")
+		default:
+			return nil, errors.New("bad token")
+		}
+	}); err != nil {
+		t.Fatalf("OnWebSocketUpgrade() error = %v", err)
+	}
+	registerIntegrationEcho(t, server, 17, 117)
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	rawURL := webSocketIntegrationURL(server, address.String(), false)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "", want: http.StatusUnauthorized},
+		{query: "?token=bad", want: http.StatusForbidden},
+		{query: "?token=panic", want: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		if got := dialWebSocketIntegrationRejected(t, rawURL+test.query, nil); got != test.want {
+			t.Fatalf("rejected upgrade %q status = %d, want %d", test.query, got, test.want)
+		}
+	}
+	if got := server.Stats().WebSocket.ActiveConnections; got != 0 {
+		t.Fatalf("WebSocket.ActiveConnections after rejected upgrades = %d, want 0", got)
+	}
+
+	client := dialWebSocketIntegration(t, nil, rawURL+"?token=valid")
+	if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 17, "accepted")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	response, err := readWebSocketIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 117, "echo:accepted")
+}
+
+func TestIntegration_WebSocketRejectsDisallowedOrigin(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithWebSocketAllowedOrigins("https://*.ramix.test"))
+	upgradeHookCalled := make(chan struct{}, 1)
+	if err := server.OnWebSocketUpgrade(func(*http.Request) (map[string]any, error) {
+		upgradeHookCalled <- struct{}{}
+		return nil, nil
+	}); err != nil {
+		t.Fatalf("OnWebSocketUpgrade() error = %v", err)
+	}
+	registerIntegrationEcho(t, server, 18, 118)
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	rawURL := webSocketIntegrationURL(server, address.String(), false)
+
+	status := dialWebSocketIntegrationRejected(t, rawURL, http.Header{"Origin": []string{"https://evil.test"}})
+	if status != http.StatusForbidden {
+		t.Fatalf("cross-origin upgrade status = %d, want %d", status, http.StatusForbidden)
+	}
+	select {
+	case <-upgradeHookCalled:
+		t.Fatal("upgrade hook ran for a rejected origin")
+	default:
+	}
+	if got := server.Stats().WebSocket.RejectedOrigins; got != 1 {
+		t.Fatalf("WebSocket.RejectedOrigins = %d, want 1", got)
+	}
+
+	dialer := websocket.Dialer{HandshakeTimeout: integrationTimeout}
+	client, response, err := dialer.Dial(rawURL, http.Header{"Origin": []string{"https://app.ramix.test"}})
+	if err != nil {
+		t.Fatalf("allowed-origin Dial() error = %v", err)
+	}
+	if response.Body != nil {
+		_ = response.Body.Close()
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	setIntegrationDeadline(t, client.UnderlyingConn())
+	if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 18, "allowed")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	message, err := readWebSocketIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, message, 118, "echo:allowed")
+}
+
+func TestIntegration_WebSocketNegotiatesSubprotocol(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithWebSocketSubprotocols("ramix.v2", "ramix.v1"))
+	subprotocols := make(chan string, 1)
+	if err := server.RegisterRoute(19, func(ctx *Context) {
+		info, _ := ConnectionInfoOf(ctx.Connection)
+		subprotocols <- info.Subprotocol
+		_ = ctx.Connection.Send(ctx, 119, nil)
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportWebSocket)
+
+	dialer := &websocket.Dialer{HandshakeTimeout: integrationTimeout, Subprotocols: []string{"ramix.v1", "ramix.v2"}}
+	client := dialWebSocketIntegration(t, dialer, webSocketIntegrationURL(server, address.String(), false))
+	if got, want := client.Subprotocol(), "ramix.v2"; got != want {
+		t.Fatalf("client Subprotocol() = %q, want %q", got, want)
+	}
+	if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 19, "")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	if _, err := readWebSocketIntegrationMessage(client); err != nil {
+		t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+	}
+	if got, want := <-subprotocols, "ramix.v2"; got != want {
+		t.Fatalf("Info().Subprotocol = %q, want %q", got, want)
+	}
+}
+
+func TestIntegration_WebSocketCompressesMessagesAboveThreshold(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithWebSocketCompression(6, 64))
+	compressed := make(chan bool, 2)
+	if err := server.RegisterRoute(20, func(ctx *Context) {
+		info, _ := ConnectionInfoOf(ctx.Connection)
+		compressed <- info.Compression
+		_ = ctx.Connection.Send(ctx, 120, ctx.Request.Message.Body)
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	rawURL := webSocketIntegrationURL(server, address.String(), false)
+
+	dialer := &websocket.Dialer{HandshakeTimeout: integrationTimeout, EnableCompression: true}
+	client := dialWebSocketIntegration(t, dialer, rawURL)
+	small := "short"
+	large := strings.Repeat("compressible ", 64)
+	for _, body := range []string{small, large} {
+		if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 20, body)); err != nil {
+			t.Fatalf("WriteMessage() error = %v", err)
+		}
+		response, err := readWebSocketIntegrationMessage(client)
+		if err != nil {
+			t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+		}
+		assertIntegrationMessage(t, response, 120, body)
+		if !<-compressed {
+			t.Fatal("Info().Compression = false, want negotiated compression")
+		}
+	}
+
+	stats := waitForIntegrationStats(t, server, func(stats ServerStats) bool {
+		return stats.WebSocket.CompressedRawBytes > 0
+	}, "compressed WebSocket bytes")
+	if got, want := stats.WebSocket.CompressedRawBytes, uint64(8+len(large)); got != want {
+		t.Fatalf("WebSocket.CompressedRawBytes = %d, want only the large message %d", got, want)
+	}
+	if stats.WebSocket.CompressedWireBytes == 0 || stats.WebSocket.CompressedWireBytes >= stats.WebSocket.CompressedRawBytes {
+		t.Fatalf("WebSocket.CompressedWireBytes = %d, want between 0 and %d", stats.WebSocket.CompressedWireBytes, stats.WebSocket.CompressedRawBytes)
+	}
+}
+
+func TestIntegration_WebSocketCompressionRequiresClientOffer(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithWebSocketCompression(1, 0))
+	compressed := make(chan bool, 1)
+	if err := server.RegisterRoute(21, func(ctx *Context) {
+		info, _ := ConnectionInfoOf(ctx.Connection)
+		compressed <- info.Compression
+		_ = ctx.Connection.Send(ctx, 121, ctx.Request.Message.Body)
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	client := dialWebSocketIntegration(t, nil, webSocketIntegrationURL(server, address.String(), false))
+
+	body := strings.Repeat("plain ", 32)
+	if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 21, body)); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	response, err := readWebSocketIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 121, body)
+	if <-compressed {
+		t.Fatal("Info().Compression = true without a client offer")
+	}
+	if stats := server.Stats(); stats.WebSocket.CompressedRawBytes != 0 || stats.WebSocket.CompressedWireBytes != 0 {
+		t.Fatalf("compression stats = (%d, %d), want zero", stats.WebSocket.CompressedRawBytes, stats.WebSocket.CompressedWireBytes)
+	}
+}
+
+func TestIntegration_WebSocketTextSubprotocolExchangesJSON(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithWebSocketTextSubprotocol("ramix.json"))
+	registerIntegrationEcho(t, server, 22, 122)
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	rawURL := webSocketIntegrationURL(server, address.String(), false)
+
+	dialer := &websocket.Dialer{HandshakeTimeout: integrationTimeout, Subprotocols: []string{"ramix.json"}}
+	client := dialWebSocketIntegration(t, dialer, rawURL)
+	if err := client.WriteMessage(websocket.TextMessage, []byte(`{"event": 22, "body": "hello"}`)); err != nil {
+		t.Fatalf("WriteMessage(text) error = %v", err)
+	}
+	messageType, payload, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if messageType != websocket.TextMessage {
+		t.Fatalf("reply message type = %d, want text", messageType)
+	}
+	if got, want := string(payload), `{"event":122,"body":"echo:hello"}`; got != want {
+		t.Fatalf("reply = %s, want %s", got, want)
+	}
+
+	if err := client.WriteMessage(websocket.TextMessage, []byte(`{"body": "no event"}`)); err != nil {
+		t.Fatalf("WriteMessage(invalid text) error = %v", err)
+	}
+	_, _, err = client.ReadMessage()
+	assertIntegrationConnectionClosed(t, err)
+
+	binaryClient := dialWebSocketIntegration(t, nil, rawURL)
+	if err := binaryClient.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 22, "binary")); err != nil {
+		t.Fatalf("binary WriteMessage() error = %v", err)
+	}
+	response, err := readWebSocketIntegrationMessage(binaryClient)
+	if err != nil {
+		t.Fatalf("binary readWebSocketIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 122, "echo:binary")
+}
+
+func TestIntegration_WebSocketServerPingsKeepQuietClientAlive(t *testing.T) {
+	server, err := NewServer(
+		WithTransports(TransportWebSocket),
+		WithIP("127.0.0.1"),
+		WithWebSocketPort(0),
+		WithWebSocketPath("/integration"),
+		WithHeartbeatInterval(30*time.Millisecond),
+		WithHeartbeatTimeout(90*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	infos := make(chan ConnectionInfo, 1)
+	if err := server.RegisterRoute(23, func(ctx *Context) {
+		info, _ := ConnectionInfoOf(ctx.Connection)
+		infos <- info
+		_ = ctx.Connection.Send(ctx, 123, nil)
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	client := dialWebSocketIntegration(t, nil, webSocketIntegrationURL(server, address.String(), false))
+
+	messages := make(chan Message, 1)
+	readErrors := make(chan error, 1)
+	go func() {
+		for {
+			message, err := readWebSocketIntegrationMessage(client)
+			if err != nil {
+				readErrors <- err
+				return
+			}
+			messages <- message
+		}
+	}()
+	select {
+	case err := <-readErrors:
+		t.Fatalf("quiet client was disconnected: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 23, "")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	select {
+	case message := <-messages:
+		assertIntegrationMessage(t, message, 123, "")
+	case err := <-readErrors:
+		t.Fatalf("read error = %v", err)
+	case <-time.After(integrationTimeout):
+		t.Fatal("timed out waiting for reply")
+	}
+	if info := <-infos; info.RTT <= 0 {
+		t.Fatalf("Info().RTT = %s, want measured round-trip time", info.RTT)
+	}
+}
+
+func TestIntegration_WebSocketTrustedProxyForwardsClientAddress(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithWebSocketTrustedProxies("127.0.0.1"))
+	hookAddresses := make(chan string, 1)
+	if err := server.OnWebSocketUpgrade(func(request *http.Request) (map[string]any, error) {
+		hookAddresses <- request.RemoteAddr
+		return nil, nil
+	}); err != nil {
+		t.Fatalf("OnWebSocketUpgrade() error = %v", err)
+	}
+	if err := server.RegisterRoute(22, func(ctx *Context) {
+		_ = ctx.Connection.Send(ctx, 122, []byte(ctx.Connection.RemoteAddress().String()))
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportWebSocket)
+
+	dialer := websocket.Dialer{HandshakeTimeout: integrationTimeout}
+	client, response, err := dialer.Dial(webSocketIntegrationURL(server, address.String(), false), http.Header{
+		"X-Forwarded-For": []string{"203.0.113.7, 127.0.0.1"},
+	})
+	if err != nil {
+		t.Fatalf("websocket Dial() error = %v", err)
+	}
+	if response.Body != nil {
+		_ = response.Body.Close()
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	setIntegrationDeadline(t, client.UnderlyingConn())
+
+	if got := <-hookAddresses; got != "203.0.113.7:0" {
+		t.Fatalf("upgrade hook RemoteAddr = %q, want 203.0.113.7:0", got)
+	}
+	if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 22, "")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	message, err := readWebSocketIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, message, 122, "203.0.113.7:0")
+}
+
+func TestIntegration_WebSocketIgnoresForwardedHeadersFromUntrustedPeer(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithWebSocketTrustedProxies("10.0.0.0/8"))
+	if err := server.RegisterRoute(23, func(ctx *Context) {
+		_ = ctx.Connection.Send(ctx, 123, []byte(ctx.Connection.RemoteAddress().String()))
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportWebSocket)
+
+	dialer := websocket.Dialer{HandshakeTimeout: integrationTimeout}
+	client, response, err := dialer.Dial(webSocketIntegrationURL(server, address.String(), false), http.Header{
+		"X-Real-IP": []string{"203.0.113.7"},
+	})
+	if err != nil {
+		t.Fatalf("websocket Dial() error = %v", err)
+	}
+	if response.Body != nil {
+		_ = response.Body.Close()
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	setIntegrationDeadline(t, client.UnderlyingConn())
+
+	if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 23, "")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	message, err := readWebSocketIntegrationMessage(client)
+	if err != nil {
+		t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, message, 123, client.LocalAddr().String())
+}
+
+func TestIntegration_TLSCanBeLimitedToTCP(t *testing.T) {
+	server, err := NewServer(
+		WithTransports(TransportTCP, TransportWebSocket),
+		WithIP("127.0.0.1"),
+		WithPort(0),
+		WithWebSocketPort(0),
+		WithHeartbeatInterval(time.Hour),
+		WithHeartbeatTimeout(2*time.Hour),
+		WithCertFile("examples/tls/public_certificate.pem"),
+		WithPrivateKeyFile("examples/tls/private_key.pem"),
+		WithTLSTransports(TransportTCP),
+	)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	registerIntegrationEcho(t, server, 36, 136)
+	address := startIntegrationServer(t, server, TransportTCP)
+
+	// #nosec G402 -- test fixture intentionally bypasses certificate verification.
+	config := &tls.Config{InsecureSkipVerify: true}
+	dialer := net.Dialer{Timeout: integrationTimeout}
+	secure, err := tls.DialWithDialer(&dialer, "tcp", address.String(), config)
+	if err != nil {
+		t.Fatalf("tls.DialWithDialer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = secure.Close() })
+	setIntegrationDeadline(t, secure)
+	if _, err := secure.Write(encodeIntegrationMessage(t, 36, "tcp")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	response, err := readIntegrationMessage(secure)
+	if err != nil {
+		t.Fatalf("readIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, response, 136, "echo:tcp")
+
+	plain := dialWebSocketIntegration(t, &websocket.Dialer{HandshakeTimeout: integrationTimeout},
+		webSocketIntegrationURL(server, server.Address(TransportWebSocket).String(), false))
+	if err := plain.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 36, "ws")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	message, err := readWebSocketIntegrationMessage(plain)
+	if err != nil {
+		t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+	}
+	assertIntegrationMessage(t, message, 136, "echo:ws")
+}
+
+func TestIntegration_WebSocketMutualTLSExposesClientCertificate(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	server := newWebSocketIntegrationServer(t,
+		WithTLSConfig(&tls.Config{
+			ClientAuth: tls.VerifyClientCertIfGiven,
+			ClientCAs:  ca.pool(),
+			MinVersion: tls.VersionTLS12,
+		}),
+		WithCertFile("examples/tls/public_certificate.pem"),
+		WithPrivateKeyFile("examples/tls/private_key.pem"),
+	)
+	if err := server.RegisterRoute(24, func(ctx *Context) {
+		info, _ := ConnectionInfoOf(ctx.Connection)
+		subject := "anonymous"
+		if info.PeerCertificate != nil {
+			subject = info.PeerCertificate.Subject.CommonName
+		}
+		_ = ctx.Connection.Send(ctx, 124, []byte(subject))
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	rawURL := webSocketIntegrationURL(server, address.String(), true)
+
+	for _, test := range []struct {
+		name         string
+		certificates []tls.Certificate
+		want         string
+	}{
+		{name: "client certificate", certificates: []tls.Certificate{ca.issueClient(t, "bob")}, want: "bob"},
+		{name: "no client certificate", want: "anonymous"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dialer := &websocket.Dialer{
+				HandshakeTimeout: integrationTimeout,
+				// #nosec G402 -- test fixture intentionally bypasses server certificate verification.
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true, Certificates: test.certificates},
+			}
+			client := dialWebSocketIntegration(t, dialer, rawURL)
+			if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 24, "")); err != nil {
+				t.Fatalf("WriteMessage() error = %v", err)
+			}
+			message, err := readWebSocketIntegrationMessage(client)
+			if err != nil {
+				t.Fatalf("readWebSocketIntegrationMessage() error = %v", err)
+			}
+			assertIntegrationMessage(t, message, 124, test.want)
+		})
+	}
+}
+
+func TestIntegration_WebSocketAcceptRateLimitRejectsUpgrade(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithAcceptRateLimit(0.001, 1))
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	rawURL := webSocketIntegrationURL(server, address.String(), false)
+
+	dialWebSocketIntegration(t, &websocket.Dialer{HandshakeTimeout: integrationTimeout}, rawURL)
+	if status := dialWebSocketIntegrationRejected(t, rawURL, nil); status != http.StatusTooManyRequests {
+		t.Fatalf("rate-limited upgrade status = %d, want %d", status, http.StatusTooManyRequests)
+	}
+	stats := server.Stats()
+	if stats.WebSocket.RejectedAcceptRate != 1 || stats.Total.RejectedAcceptRate != 1 {
+		t.Fatalf("RejectedAcceptRate = websocket %d total %d, want 1", stats.WebSocket.RejectedAcceptRate, stats.Total.RejectedAcceptRate)
+	}
+}
+
+func TestIntegration_WebSocketIPFilterRejectsUpgrade(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithIPFilter(IPFilter{Allow: []string{"10.0.0.0/8"}}))
+	address := startIntegrationServer(t, server, TransportWebSocket)
+
+	if status := dialWebSocketIntegrationRejected(t, webSocketIntegrationURL(server, address.String(), false), nil); status != http.StatusForbidden {
+		t.Fatalf("denied upgrade status = %d, want %d", status, http.StatusForbidden)
+	}
+	if got := server.Stats().WebSocket.RejectedDeniedIPs; got != 1 {
+		t.Fatalf("WebSocket.RejectedDeniedIPs = %d, want 1", got)
+	}
+}
+
+func TestIntegration_WebSocketServerFullAppliesIPFilterFirst(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithMaxConnectionsCount(1))
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	rawURL := webSocketIntegrationURL(server, address.String(), false)
+	dialWebSocketIntegration(t, &websocket.Dialer{HandshakeTimeout: integrationTimeout}, rawURL)
+	waitForIntegrationStats(t, server, func(stats ServerStats) bool {
+		return stats.WebSocket.ActiveConnections == 1
+	}, "first connection to open")
+	if _, err := server.SetIPFilter(IPFilter{Deny: []string{"127.0.0.0/8"}}, false); err != nil {
+		t.Fatalf("SetIPFilter() error = %v", err)
+	}
+
+	if status := dialWebSocketIntegrationRejected(t, rawURL, nil); status != http.StatusForbidden {
+		t.Fatalf("denied upgrade status = %d, want %d", status, http.StatusForbidden)
+	}
+	stats := server.Stats()
+	if stats.WebSocket.RejectedDeniedIPs != 1 || stats.WebSocket.RejectedServerFull != 0 {
+		t.Fatalf("WebSocket rejections = denied %d full %d, want denied 1 full 0", stats.WebSocket.RejectedDeniedIPs, stats.WebSocket.RejectedServerFull)
+	}
+}
+
+func TestIntegration_WebSocketServerFullRejection(t *testing.T) {
+	t.Run("http status", func(t *testing.T) {
+		server := newWebSocketIntegrationServer(t, WithMaxConnectionsCount(1), WithServerFullRetryAfter(5*time.Second))
+		address := startIntegrationServer(t, server, TransportWebSocket)
+		rawURL := webSocketIntegrationURL(server, address.String(), false)
+		dialWebSocketIntegration(t, &websocket.Dialer{HandshakeTimeout: integrationTimeout}, rawURL)
+		waitForIntegrationStats(t, server, func(stats ServerStats) bool {
+			return stats.WebSocket.ActiveConnections == 1
+		}, "first connection to open")
+
+		dialer := websocket.Dialer{HandshakeTimeout: integrationTimeout}
+		connection, response, err := dialer.Dial(rawURL, nil)
+		if err == nil {
+			_ = connection.Close()
+			t.Fatal("websocket Dial() succeeded, want rejected upgrade")
+		}
+		if response == nil {
+			t.Fatalf("websocket Dial() error = %v, want HTTP rejection", err)
+		}
+		_ = response.Body.Close()
+		if response.StatusCode != http.StatusServiceUnavailable || response.Header.Get("Retry-After") != "5" {
+			t.Fatalf("rejection = %d Retry-After %q, want 503 Retry-After 5", response.StatusCode, response.Header.Get("Retry-After"))
+		}
+		if got := server.Stats().WebSocket.RejectedServerFull; got != 1 {
+			t.Fatalf("WebSocket.RejectedServerFull = %d, want 1", got)
+		}
+	})
+
+	t.Run("close code", func(t *testing.T) {
+		server := newWebSocketIntegrationServer(t,
+			WithMaxConnectionsCount(1),
+			WithServerFullCloseCode(websocket.CloseTryAgainLater),
+			WithServerFullRetryAfter(5*time.Second),
+		)
+		address := startIntegrationServer(t, server, TransportWebSocket)
+		rawURL := webSocketIntegrationURL(server, address.String(), false)
+		dialWebSocketIntegration(t, &websocket.Dialer{HandshakeTimeout: integrationTimeout}, rawURL)
+		waitForIntegrationStats(t, server, func(stats ServerStats) bool {
+			return stats.WebSocket.ActiveConnections == 1
+		}, "first connection to open")
+
+		rejected := dialWebSocketIntegration(t, &websocket.Dialer{HandshakeTimeout: integrationTimeout}, rawURL)
+		_, _, err := rejected.ReadMessage()
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("ReadMessage() error = %v, want close error", err)
+		}
+		if closeErr.Code != websocket.CloseTryAgainLater || closeErr.Text != "server full; retry after 5" {
+			t.Fatalf("close = (%d, %q), want (%d, %q)", closeErr.Code, closeErr.Text, websocket.CloseTryAgainLater, "server full; retry after 5")
+		}
+	})
+}
+
+func TestIntegration_WebSocketBatchingSendsFramesInOneMessage(t *testing.T) {
+	server := newWebSocketIntegrationServer(t, WithWebSocketBatching(true), WithWriteFlushDelay(100*time.Millisecond))
+	if err := server.RegisterRoute(12, func(ctx *Context) {
+		for _, body := range []string{"first", "second", "third"} {
+			_ = ctx.Connection.Send(ctx, 112, []byte(body))
+		}
+	}); err != nil {
+		t.Fatalf("RegisterRoute() error = %v", err)
+	}
+	address := startIntegrationServer(t, server, TransportWebSocket)
+	client := dialWebSocketIntegration(t, nil, webSocketIntegrationURL(server, address.String(), false))
+
+	if err := client.WriteMessage(websocket.BinaryMessage, encodeIntegrationMessage(t, 12, "burst")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	messageType, payload, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if messageType != websocket.BinaryMessage {
+		t.Fatalf("message type = %d, want binary", messageType)
+	}
+	decoder, err := NewFrameDecoder(WithLengthFieldOffset(4), WithLengthFieldLength(4))
+	if err != nil {
+		t.Fatalf("NewFrameDecoder() error = %v", err)
+	}
+	frames, err := decoder.Decode(payload)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("frames in message = %d, want 3", len(frames))
+	}
+	for i, body := range []string{"first", "second", "third"} {
+		message, err := (&Decoder{}).Decode(frames[i])
+		if err != nil {
+			t.Fatalf("Decode(frame %d) error = %v", i, err)
+		}
+		assertIntegrationMessage(t, message, 112, body)
+	}
+}
@@ -0,0 +1,133 @@
+package ramix
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+type originPattern struct {
+	scheme   string
+	host     string
+	wildcard bool
+	port     bool
+}
+
+func parseOriginPatterns(origins []string) ([]originPattern, error) {
+	patterns := make([]originPattern, 0, len(origins))
+	for _, origin := range origins {
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func parseOriginPattern(origin string) (originPattern, error) {
+	origin = strings.ToLower(strings.TrimSpace(origin))
+	if origin == "*" {
+		return originPattern{wildcard: true}, nil
+	}
+
+	var pattern originPattern
+	host := origin
+	if scheme, rest, ok := strings.Cut(origin, "://"); ok {
+		pattern.scheme = scheme
+		host = rest
+	}
+	if strings.HasPrefix(host, "*.") {
+		pattern.wildcard = true
+		host = host[2:]
+	}
+	if host == "" || strings.ContainsAny(host, "*/?#@") {
+		return originPattern{}, fmt.Errorf("%w: invalid websocket origin %q", ErrInvalidConfiguration, origin)
+	}
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		pattern.port = true
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	pattern.host = host
+	return pattern, nil
+}
+
+func (p originPattern) matches(origin *url.URL) bool {
+	if p.wildcard && p.host == "" {
+		return true
+	}
+	if p.scheme != "" && p.scheme != strings.ToLower(origin.Scheme) {
+		return false
+	}
+	host := strings.ToLower(origin.Hostname())
+	if p.port {
+		host = strings.ToLower(origin.Host)
+	}
+	if !p.wildcard {
+		return host == p.host
+	}
+	return strings.HasSuffix(host, "."+p.host)
+}
+
+func (s *Server) checkWebSocketOrigin(request *http.Request) bool {
+	if s.WebSocketCheckOrigin != nil {
+		return s.WebSocketCheckOrigin(request)
+	}
+	if len(request.Header["Origin"]) == 0 {
+		return true
+	}
+	if len(s.WebSocketAllowedOrigins) == 0 {
+		return sameOrigin(request)
+	}
+	origin, err := url.Parse(request.Header.Get("Origin"))
+	if err != nil || origin.Host == "" {
+		return false
+	}
+	for _, pattern := range s.originPatterns {
+		if pattern.matches(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameOrigin(request *http.Request) bool {
+	origin, err := url.Parse(request.Header.Get("Origin"))
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(origin.Host, request.Host)
+}
+
+func (s *Server) configureWebSocketUpgrader() error {
+	patterns, err := parseOriginPatterns(s.WebSocketAllowedOrigins)
+	if err != nil {
+		return err
+	}
+	s.originPatterns = patterns
+	subprotocols := append([]string(nil), s.WebSocketSubprotocols...)
+	if s.WebSocketTextSubprotocol != "" && !containsString(subprotocols, s.WebSocketTextSubprotocol) {
+		subprotocols = append(subprotocols, s.WebSocketTextSubprotocol)
+	}
+	s.upgrader = &websocket.Upgrader{
+		ReadBufferSize:    int(s.ConnectionReadBufferSize),
+		Subprotocols:      subprotocols,
+		EnableCompression: s.WebSocketCompression,
+		CheckOrigin:       func(*http.Request) bool { return true },
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
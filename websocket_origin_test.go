@@ -0,0 +1,74 @@
+package ramix
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckWebSocketOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		host    string
+		origin  string
+		want    bool
+	}{
+		{name: "missing origin", host: "ramix.test", want: true},
+		{name: "same origin by default", host: "ramix.test:8900", origin: "https://ramix.test:8900", want: true},
+		{name: "cross origin by default", host: "ramix.test:8900", origin: "https://evil.test", want: false},
+		{name: "wildcard allows any origin", allowed: []string{"*"}, host: "ramix.test", origin: "https://evil.test", want: true},
+		{name: "exact origin", allowed: []string{"https://app.test"}, host: "ramix.test", origin: "https://app.test", want: true},
+		{name: "exact origin is case-insensitive", allowed: []string{"https://App.Test"}, host: "ramix.test", origin: "https://app.TEST", want: true},
+		{name: "exact origin rejects other scheme", allowed: []string{"https://app.test"}, host: "ramix.test", origin: "http://app.test", want: false},
+		{name: "origin without port matches any port", allowed: []string{"https://app.test"}, host: "ramix.test", origin: "https://app.test:8443", want: true},
+		{name: "origin with port", allowed: []string{"https://app.test:8443"}, host: "ramix.test", origin: "https://app.test:8443", want: true},
+		{name: "origin with port rejects other port", allowed: []string{"https://app.test:8443"}, host: "ramix.test", origin: "https://app.test:9443", want: false},
+		{name: "origin with port rejects default port", allowed: []string{"https://app.test:8443"}, host: "ramix.test", origin: "https://app.test", want: false},
+		{name: "ipv6 origin without port", allowed: []string{"http://[::1]"}, host: "ramix.test", origin: "http://[::1]:8080", want: true},
+		{name: "host pattern allows any scheme", allowed: []string{"app.test"}, host: "ramix.test", origin: "http://app.test", want: true},
+		{name: "subdomain wildcard", allowed: []string{"https://*.app.test"}, host: "ramix.test", origin: "https://eu.cdn.app.test", want: true},
+		{name: "subdomain wildcard excludes apex", allowed: []string{"https://*.app.test"}, host: "ramix.test", origin: "https://app.test", want: false},
+		{name: "subdomain wildcard rejects suffix lookalike", allowed: []string{"https://*.app.test"}, host: "ramix.test", origin: "https://evilapp.test", want: false},
+		{name: "malformed origin", allowed: []string{"*.app.test"}, host: "ramix.test", origin: "://", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewServer(WithWebSocketAllowedOrigins(tt.allowed...))
+			if err != nil {
+				t.Fatalf("NewServer() error = %v", err)
+			}
+			request := httptest.NewRequest(http.MethodGet, "http://"+tt.host+"/ws", nil)
+			if tt.origin != "" {
+				request.Header.Set("Origin", tt.origin)
+			}
+			if got := server.checkWebSocketOrigin(request); got != tt.want {
+				t.Fatalf("checkWebSocketOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckWebSocketOriginPrefersCustomCheck(t *testing.T) {
+	var checked *http.Request
+	server, err := NewServer(
+		WithWebSocketAllowedOrigins("*"),
+		WithWebSocketCheckOrigin(func(request *http.Request) bool {
+			checked = request
+			return false
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	request := httptest.NewRequest(http.MethodGet, "http://ramix.test/ws", nil)
+	request.Header.Set("Origin", "https://app.test")
+
+	if server.checkWebSocketOrigin(request) {
+		t.Fatal("checkWebSocketOrigin() = true, want custom check result false")
+	}
+	if checked != request {
+		t.Fatal("custom origin check was not called with the upgrade request")
+	}
+}
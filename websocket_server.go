@@ -1,10 +1,30 @@
 package ramix
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 )
 
+type WebSocketUpgradeHandler func(*http.Request) (map[string]any, error)
+
+type UpgradeRejectedError struct {
+	StatusCode int
+	Reason     string
+}
+
+func (e *UpgradeRejectedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket upgrade rejected: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("websocket upgrade rejected: %d %s", e.StatusCode, e.Reason)
+}
+
+func RejectUpgrade(statusCode int, reason string) error {
+	return &UpgradeRejectedError{StatusCode: statusCode, Reason: reason}
+}
+
 func (s *Server) serveWebSocket(listener net.Listener) error {
 	err := s.webSocketServer.Serve(listener)
 	if s.expectedServingError(err) {
@@ -13,19 +33,103 @@ func (s *Server) serveWebSocket(listener net.Listener) error {
 	return err
 }
 
+func (s *Server) OnWebSocketUpgrade(callback WebSocketUpgradeHandler) error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if err := s.mutationErrorLocked(); err != nil {
+		return err
+	}
+	s.webSocketUpgrade = callback
+	return nil
+}
+
 func (s *Server) handleWebSocketUpgrade(writer http.ResponseWriter, request *http.Request) {
 	if !s.beginConnectionSetup() {
 		http.Error(writer, "server stopping", http.StatusServiceUnavailable)
 		return
 	}
 	defer s.finishConnectionSetup()
-	if s.connectionManager.connectionsCount() >= s.MaxConnectionsCount {
-		http.Error(writer, "connection limit reached", http.StatusServiceUnavailable)
+	remoteAddress := s.forwardedClientAddress(request)
+	if remoteAddress != nil {
+		request.RemoteAddr = remoteAddress.String()
+	}
+	if !s.checkWebSocketOrigin(request) {
+		s.metrics.originRejected(TransportWebSocket)
+		http.Error(writer, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	clientAddress := remoteAddress
+	if clientAddress == nil {
+		clientAddress = requestAddress(request)
+	}
+	release, err := s.admitClient(TransportWebSocket, clientAddress)
+	if err != nil {
+		statusCode := http.StatusTooManyRequests
+		if errors.Is(err, ErrIPDenied) {
+			statusCode = http.StatusForbidden
+		}
+		http.Error(writer, err.Error(), statusCode)
+		return
+	}
+	if s.serverFull() {
+		release()
+		s.metrics.serverFullRejected(TransportWebSocket)
+		s.reportConnectionRejected(TransportWebSocket, clientAddress, ErrServerFull)
+		s.rejectFullWebSocketUpgrade(writer, request)
+		return
+	}
+	attributes, err := s.invokeUpgradeHook(request)
+	if err != nil {
+		release()
+		rejectWebSocketUpgrade(writer, err)
 		return
 	}
 	socket, err := s.upgrader.Upgrade(writer, request, nil)
 	if err != nil {
+		release()
+		return
+	}
+	compression := s.WebSocketCompression && offersCompression(request.Header)
+	s.openWebSocketConnection(socket, s.nextConnectionID(), remoteAddress, attributes, compression, release)
+}
+
+func (s *Server) upgradeHook() WebSocketUpgradeHandler {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.runtimeUpgrade != nil {
+		return s.runtimeUpgrade
+	}
+	return s.webSocketUpgrade
+}
+
+func (s *Server) invokeUpgradeHook(request *http.Request) (attributes map[string]any, err error) {
+	callback := s.upgradeHook()
+	if callback == nil {
+		return nil, nil
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			debug("WebSocket upgrade hook panic: %v", recovered)
+			attributes = nil
+			err = RejectUpgrade(http.StatusInternalServerError, "")
+		}
+	}()
+	return callback(request)
+}
+
+func rejectWebSocketUpgrade(writer http.ResponseWriter, err error) {
+	var rejected *UpgradeRejectedError
+	if !errors.As(err, &rejected) {
+		http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 		return
 	}
-	s.openWebSocketConnection(socket, s.nextConnectionID())
+	statusCode := rejected.StatusCode
+	if statusCode < 400 || statusCode > 599 {
+		statusCode = http.StatusForbidden
+	}
+	reason := rejected.Reason
+	if reason == "" {
+		reason = http.StatusText(statusCode)
+	}
+	http.Error(writer, reason, statusCode)
 }
@@ -0,0 +1,131 @@
+package ramix
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+const textBodyBase64 = "base64"
+
+type textEnvelope struct {
+	Event    *uint32         `json:"event"`
+	Encoding string          `json:"encoding,omitempty"`
+	Body     json.RawMessage `json:"body,omitempty"`
+}
+
+func decodeTextMessage(data []byte) (Message, error) {
+	var envelope textEnvelope
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&envelope); err != nil {
+		return Message{}, fmt.Errorf("%w: invalid websocket text message: %v", ErrInvalidFrame, err)
+	}
+	if decoder.More() {
+		return Message{}, fmt.Errorf("%w: websocket text message has trailing data", ErrInvalidFrame)
+	}
+	if envelope.Event == nil {
+		return Message{}, fmt.Errorf("%w: websocket text message has no event", ErrInvalidFrame)
+	}
+
+	body := []byte(envelope.Body)
+	switch {
+	case envelope.Encoding == textBodyBase64:
+		var encoded string
+		if err := json.Unmarshal(body, &encoded); err != nil {
+			return Message{}, fmt.Errorf("%w: websocket text message base64 body is not a string", ErrInvalidFrame)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return Message{}, fmt.Errorf("%w: invalid websocket text message base64 body: %v", ErrInvalidFrame, err)
+		}
+		body = decoded
+	case envelope.Encoding != "":
+		return Message{}, fmt.Errorf("%w: unsupported websocket text message encoding %q", ErrInvalidFrame, envelope.Encoding)
+	case len(body) == 0 || bytes.Equal(body, []byte("null")):
+		body = nil
+	case body[0] == '"':
+		var text string
+		if err := json.Unmarshal(body, &text); err != nil {
+			return Message{}, fmt.Errorf("%w: invalid websocket text message body: %v", ErrInvalidFrame, err)
+		}
+		body = []byte(text)
+	}
+	if err := validateEncodedBodyLength(uint64(len(body)), maxEncodedBodyLength()); err != nil {
+		return Message{}, err
+	}
+
+	return Message{Event: *envelope.Event, Body: body, BodySize: uint32(len(body))}, nil
+}
+
+func encodeTextMessage(frame []byte) ([]byte, error) {
+	if len(frame) < 8 {
+		return nil, fmt.Errorf("%w: frame too short: got %d bytes, need at least 8", ErrInvalidFrame, len(frame))
+	}
+	event := binary.LittleEndian.Uint32(frame[0:4])
+	body := frame[8:]
+
+	text := []byte(`{"event":`)
+	text = strconv.AppendUint(text, uint64(event), 10)
+	switch {
+	case len(body) == 0:
+	case rawJSONBody(body):
+		text = append(text, `,"body":`...)
+		text = append(text, body...)
+	case utf8.Valid(body):
+		encoded, err := json.Marshal(string(body))
+		if err != nil {
+			return nil, err
+		}
+		text = append(text, `,"body":`...)
+		text = append(text, encoded...)
+	default:
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(len(body)))
+		base64.StdEncoding.Encode(encoded, body)
+		text = append(text, `,"encoding":"`+textBodyBase64+`","body":"`...)
+		text = append(text, encoded...)
+		text = append(text, '"')
+	}
+	return append(text, '}'), nil
+}
+
+func rawJSONBody(body []byte) bool {
+	switch body[0] {
+	case '"', 'n', ' ', '\t', '\r', '\n':
+		return false
+	}
+	switch body[len(body)-1] {
+	case ' ', '\t', '\r', '\n':
+		return false
+	}
+	return json.Valid(body)
+}
+
+func (c *WebSocketConnection) writeFrames(frames [][]byte) error {
+	return c.writeDataMessage(websocket.BinaryMessage, bytes.Join(frames, nil))
+}
+
+func maxWebSocketBatchBytes(maxFrameLength uint64) int {
+	if maxFrameLength > math.MaxInt {
+		return math.MaxInt
+	}
+	return int(maxFrameLength)
+}
+
+func (c *WebSocketConnection) writeFrame(frame []byte) error {
+	if !c.textMessages {
+		return c.writeDataMessage(websocket.BinaryMessage, frame)
+	}
+	text, err := encodeTextMessage(frame)
+	if err != nil {
+		return err
+	}
+	return c.writeDataMessage(websocket.TextMessage, text)
+}
@@ -0,0 +1,109 @@
+package ramix
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeTextMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		event uint32
+		body  string
+	}{
+		{name: "string body", input: `{"event": 7, "body": "hello"}`, event: 7, body: "hello"},
+		{name: "object body", input: `{"event": 8, "body": {"room": "lobby"}}`, event: 8, body: `{"room": "lobby"}`},
+		{name: "number body", input: `{"event": 9, "body": 42}`, event: 9, body: "42"},
+		{name: "missing body", input: `{"event": 10}`, event: 10},
+		{name: "null body", input: `{"event": 11, "body": null}`, event: 11},
+		{name: "escaped string body", input: `{"event": 12, "body": "line\nbreak"}`, event: 12, body: "line\nbreak"},
+		{name: "base64 body", input: `{"event": 13, "encoding": "base64", "body": "/wA="}`, event: 13, body: "\xff\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := decodeTextMessage([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("decodeTextMessage() error = %v", err)
+			}
+			if message.Event != tt.event || string(message.Body) != tt.body || message.BodySize != uint32(len(tt.body)) {
+				t.Fatalf("decodeTextMessage() = (%d, %q, %d), want (%d, %q, %d)",
+					message.Event, message.Body, message.BodySize, tt.event, tt.body, len(tt.body))
+			}
+		})
+	}
+}
+
+func TestDecodeTextMessageRejectsInvalidEnvelope(t *testing.T) {
+	inputs := map[string]string{
+		"not json":         `hello`,
+		"missing event":    `{"body": "hello"}`,
+		"negative event":   `{"event": -1}`,
+		"unknown field":    `{"event": 1, "extra": true}`,
+		"trailing data":    `{"event": 1} {"event": 2}`,
+		"array":            `[1, "hello"]`,
+		"unknown encoding": `{"event": 1, "encoding": "hex", "body": "ff"}`,
+		"invalid base64":   `{"event": 1, "encoding": "base64", "body": "%%"}`,
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeTextMessage([]byte(input)); !errors.Is(err, ErrInvalidFrame) {
+				t.Fatalf("decodeTextMessage(%q) error = %v, want %v", input, err, ErrInvalidFrame)
+			}
+		})
+	}
+}
+
+func TestEncodeTextMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "plain text body", body: "pong", want: `{"event":3,"body":"pong"}`},
+		{name: "json body", body: `{"ok": true}`, want: `{"event":3,"body":{"ok": true}}`},
+		{name: "json string body", body: `"pong"`, want: `{"event":3,"body":"\"pong\""}`},
+		{name: "json body with padding", body: ` 42`, want: `{"event":3,"body":" 42"}`},
+		{name: "binary body", body: "\xff\x00", want: `{"event":3,"encoding":"base64","body":"/wA="}`},
+		{name: "empty body", body: "", want: `{"event":3}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := (&Encoder{}).Encode(Message{Event: 3, Body: []byte(tt.body)})
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			got, err := encodeTextMessage(frame)
+			if err != nil {
+				t.Fatalf("encodeTextMessage() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("encodeTextMessage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextMessageRoundTripKeepsBody(t *testing.T) {
+	bodies := []string{"pong", `"pong"`, `{"ok": true}`, "42", "null", " 42", "<b>&</b>", "\xff\xfe binary"}
+	for _, body := range bodies {
+		frame, err := (&Encoder{}).Encode(Message{Event: 3, Body: []byte(body)})
+		if err != nil {
+			t.Fatalf("Encode(%q) error = %v", body, err)
+		}
+		text, err := encodeTextMessage(frame)
+		if err != nil {
+			t.Fatalf("encodeTextMessage(%q) error = %v", body, err)
+		}
+		message, err := decodeTextMessage(text)
+		if err != nil {
+			t.Fatalf("decodeTextMessage(%s) error = %v", text, err)
+		}
+		if message.Event != 3 || string(message.Body) != body {
+			t.Fatalf("round trip of %q = (%d, %q) via %s", body, message.Event, message.Body, text)
+		}
+	}
+}
@@ -1,49 +1,417 @@
 package ramix
 
-import "time"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type connectionHold struct {
+	owner *Context
+	tasks []*Context
+}
 
 type worker struct {
-	id    int
-	tasks chan *Context
-	pool  *workerPool
-	done  chan struct{}
+	id       int
+	pool     *workerPool
+	capacity int
+
+	mu       sync.Mutex
+	ordered  [priorityLevels]taskQueue
+	shared   [priorityLevels]taskDeque
+	claimed  bool
+	held     map[uint64]*connectionHold
+	parked   int
+	bypassed [priorityLevels]int
+	shedder  queueShedder
+
+	wake   chan struct{}
+	space  chan struct{}
+	idle   atomic.Bool
+	active atomic.Bool
+
+	running atomic.Int32
 }
 
-func (w *worker) start() {
-	go func() {
-		defer close(w.done)
+func (w *worker) serve() {
+	defer w.pool.runnerStopped(w)
+
+	for {
+		task, owner := w.next()
+		if task == nil {
+			break
+		}
+		owner.run(task)
+	}
 
-		for task := range w.tasks {
-			if task == nil {
-				continue
+	debug("Worker %d stopped", w.id)
+}
+
+func (w *worker) next() (*Context, *worker) {
+	var idleTimeout <-chan time.Time
+	if w.pool.autoscale.enabled() {
+		timer := time.NewTimer(w.pool.autoscale.idleTimeout)
+		defer timer.Stop()
+		idleTimeout = timer.C
+	}
+
+	for {
+		if task, owner := w.pool.take(w); task != nil {
+			return task, owner
+		}
+
+		w.idle.Store(true)
+		if task, owner := w.pool.take(w); task != nil {
+			w.idle.Store(false)
+			return task, owner
+		}
+		select {
+		case <-w.wake:
+		case <-idleTimeout:
+			if w.pool.retire(w) {
+				return nil, nil
 			}
+			idleTimeout = nil
+		case <-w.pool.drain:
+			w.idle.Store(false)
+			return w.pool.take(w)
+		}
+		w.idle.Store(false)
+	}
+}
 
-			func() {
-				task.taskDequeued()
-				defer task.finish()
-				defer w.pool.unregister(task)
+func (w *worker) run(task *Context) {
+	w.running.Add(1)
+	defer w.running.Add(-1)
+	task.taskDequeued()
+	if task.orderIndependent {
+		w.execute(task)
+		return
+	}
+	defer w.release()
+	if w.park(task) {
+		task.taskQueued()
+		return
+	}
 
-				if task.Err() != nil {
-					return
-				}
+	tasks := []*Context{task}
+	for i := 0; i < len(tasks); i++ {
+		if i > 0 {
+			w.unpark()
+			tasks[i].taskDequeued()
+		}
+		if !w.execute(tasks[i]) {
+			w.hold(tasks[i], tasks[i+1:])
+			return
+		}
+		tasks = append(tasks, w.unhold(tasks[i])...)
+	}
+}
 
-				started := time.Now()
-				task.Next()
-				task.requestCompleted(time.Since(started))
-			}()
+func (w *worker) execute(task *Context) bool {
+	runnable := task.Err() == nil && !w.drop(task)
+	if task.bulkhead != nil {
+		switch {
+		case runnable:
+			admitted, queued := task.bulkhead.enter(task, w)
+			if queued {
+				task.taskQueued()
+				return false
+			}
+			runnable = admitted
+			if admitted {
+				defer task.bulkhead.leave(task)
+			}
+		case task.bulkheadSlot:
+			task.bulkhead.leave(task)
 		}
+	}
+	defer task.finish()
+	defer w.pool.unregister(task)
+
+	if !runnable {
+		return true
+	}
 
-		debug("Worker %d stopped", w.id)
-	}()
+	started := time.Now()
+	task.Next()
+	task.requestCompleted(time.Since(started))
+	return true
+}
+
+func (w *worker) hold(owner *Context, tasks []*Context) {
+	if owner.Connection == nil {
+		return
+	}
+	w.mu.Lock()
+	if w.held == nil {
+		w.held = make(map[uint64]*connectionHold)
+	}
+	w.held[owner.Connection.ID()] = &connectionHold{owner: owner, tasks: tasks}
+	w.mu.Unlock()
+}
 
-	debug("Worker %d started", w.id)
+func (w *worker) park(task *Context) bool {
+	if task.Connection == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	held := w.held[task.Connection.ID()]
+	if held == nil || held.owner == task {
+		return false
+	}
+	held.tasks = append(held.tasks, task)
+	w.parked++
+	return true
+}
+
+func (w *worker) unpark() {
+	w.mu.Lock()
+	w.parked--
+	w.mu.Unlock()
+	signal(w.space)
+}
+
+func (w *worker) unhold(task *Context) []*Context {
+	if task.Connection == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := task.Connection.ID()
+	held := w.held[id]
+	if held == nil || held.owner != task {
+		return nil
+	}
+	delete(w.held, id)
+	return held.tasks
+}
+
+func (w *worker) drop(task *Context) bool {
+	now := time.Now()
+	wait := now.Sub(task.enqueued)
+	if task.maxQueueWait > 0 && wait > task.maxQueueWait {
+		task.taskExpired()
+		return true
+	}
+	if !w.shedder.enabled() {
+		return false
+	}
+
+	w.mu.Lock()
+	shed := w.shedder.shed(now, wait, w.queuedLocked() == 0)
+	w.mu.Unlock()
+
+	if shed {
+		task.taskShed()
+	}
+	return shed
+}
+
+func (w *worker) push(task *Context) bool {
+	return w.pushLimited(task, true)
+}
+
+func (w *worker) pushLimited(task *Context, limited bool) bool {
+	w.mu.Lock()
+	if limited && w.queuedLocked()+w.parked >= w.capacity {
+		w.mu.Unlock()
+		return false
+	}
+	task.enqueued = time.Now()
+	level := task.priority.level()
+	if task.orderIndependent {
+		w.shared[level].pushBack(task)
+	} else {
+		w.ordered[level].pushBack(task)
+	}
+	w.mu.Unlock()
+	task.priorityQueued()
+	return true
+}
+
+func (w *worker) take() *Context {
+	return w.takeNext(true)
+}
+
+func (w *worker) takeOrdered() *Context {
+	return w.takeNext(false)
+}
+
+func (w *worker) takeNext(includeShared bool) *Context {
+	w.mu.Lock()
+	var task *Context
+	for _, level := range w.levelOrderLocked() {
+		if !w.claimed {
+			if task = w.ordered[level].popFront(); task != nil {
+				w.claimed = true
+				break
+			}
+		}
+		if includeShared {
+			if task = w.shared[level].popFront(); task != nil {
+				break
+			}
+		}
+	}
+	if task != nil {
+		w.countBypassLocked(task.priority.level())
+	}
+	w.mu.Unlock()
+
+	w.dequeued(task)
+	return task
+}
+
+var (
+	highestPriorityFirst = [priorityLevels]int{0, 1, 2}
+	lowestPriorityFirst  = [priorityLevels]int{2, 1, 0}
+)
+
+func (w *worker) levelOrderLocked() [priorityLevels]int {
+	order := highestPriorityFirst
+	limit := w.pool.starvationLimit
+	if limit == 0 {
+		return order
+	}
+	for level := 1; level < priorityLevels; level++ {
+		if w.bypassed[level] >= limit {
+			copy(order[1:], order[:level])
+			order[0] = level
+			break
+		}
+	}
+	return order
+}
+
+func (w *worker) countBypassLocked(level int) {
+	w.bypassed[level] = 0
+	for lower := level + 1; lower < priorityLevels; lower++ {
+		if w.ordered[lower].len()+w.shared[lower].len() > 0 {
+			w.bypassed[lower]++
+		} else {
+			w.bypassed[lower] = 0
+		}
+	}
+}
+
+func (w *worker) release() {
+	w.mu.Lock()
+	w.claimed = false
+	w.mu.Unlock()
+}
+
+func (w *worker) steal() *Context {
+	w.mu.Lock()
+	var task *Context
+	for level := range w.shared {
+		if task = w.shared[level].popBack(); task != nil {
+			break
+		}
+	}
+	w.mu.Unlock()
+
+	w.dequeued(task)
+	return task
+}
+
+func (w *worker) evictOldest() *Context {
+	w.mu.Lock()
+	var task *Context
+	for _, level := range lowestPriorityFirst {
+		if task = w.ordered[level].evict(); task != nil {
+			break
+		}
+		if task = w.shared[level].popFront(); task != nil {
+			break
+		}
+	}
+	if task == nil {
+		task = w.evictParkedLocked()
+		w.mu.Unlock()
+		return task
+	}
+	w.mu.Unlock()
+
+	task.priorityDequeued()
+	return task
+}
+
+func (w *worker) evictParkedLocked() *Context {
+	var oldest *connectionHold
+	for _, held := range w.held {
+		if len(held.tasks) > 0 && (oldest == nil || held.tasks[0].enqueued.Before(oldest.tasks[0].enqueued)) {
+			oldest = held
+		}
+	}
+	if oldest == nil {
+		return nil
+	}
+	task := oldest.tasks[0]
+	oldest.tasks[0] = nil
+	oldest.tasks = oldest.tasks[1:]
+	w.parked--
+	return task
+}
+
+func (w *worker) dequeued(task *Context) {
+	if task == nil {
+		return
+	}
+	task.priorityDequeued()
+	signal(w.space)
+}
+
+func (w *worker) queued() (int, time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var oldest time.Time
+	for level := 0; level < priorityLevels; level++ {
+		for _, queue := range []taskQueue{w.ordered[level], &w.shared[level]} {
+			if front := queue.front(); front != nil && (oldest.IsZero() || front.enqueued.Before(oldest)) {
+				oldest = front.enqueued
+			}
+		}
+	}
+	return w.queuedLocked(), oldest
+}
+
+func (w *worker) queuedLocked() int {
+	var count int
+	for level := 0; level < priorityLevels; level++ {
+		count += w.ordered[level].len() + w.shared[level].len()
+	}
+	return count
+}
+
+func (w *worker) load() int {
+	w.mu.Lock()
+	queued := w.queuedLocked()
+	w.mu.Unlock()
+	return queued + int(w.running.Load())
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
 }
 
 func newWorker(workerID int, maxTasksCount uint32, pool *workerPool) *worker {
-	return &worker{
-		id:    workerID,
-		tasks: make(chan *Context, maxTasksCount),
-		pool:  pool,
-		done:  make(chan struct{}),
+	w := &worker{
+		id:       workerID,
+		pool:     pool,
+		capacity: int(maxTasksCount),
+		wake:     make(chan struct{}, 1),
+		space:    make(chan struct{}, 1),
+	}
+	for level := 0; level < priorityLevels; level++ {
+		ordered := newTaskDeque(int(maxTasksCount))
+		w.ordered[level] = &ordered
+		w.shared[level] = newTaskDeque(int(maxTasksCount))
 	}
+	return w
 }
@@ -0,0 +1,161 @@
+package ramix
+
+import (
+	"fmt"
+	"time"
+)
+
+type workerAutoscale struct {
+	minWorkers      int
+	queuedThreshold uint64
+	waitThreshold   time.Duration
+	idleTimeout     time.Duration
+	interval        time.Duration
+}
+
+func validateWorkerAutoscaling(opts ServerOptions) error {
+	if opts.MinWorkerCount == 0 && opts.MaxWorkerCount == 0 {
+		return nil
+	}
+	if opts.MinWorkerCount == 0 {
+		return fmt.Errorf("%w: min worker count must be positive", ErrInvalidConfiguration)
+	}
+	if opts.MaxWorkerCount < opts.MinWorkerCount {
+		return fmt.Errorf("%w: max worker count must be at least min worker count: min=%d max=%d", ErrInvalidConfiguration, opts.MinWorkerCount, opts.MaxWorkerCount)
+	}
+	if opts.WorkerScaleUpQueuedTasks == 0 && opts.WorkerScaleUpQueueWait <= 0 {
+		return fmt.Errorf("%w: worker scale up needs a queued tasks or queue wait threshold", ErrInvalidConfiguration)
+	}
+	if opts.WorkerScaleUpQueueWait < 0 {
+		return fmt.Errorf("%w: worker scale up queue wait must not be negative: %s", ErrInvalidConfiguration, opts.WorkerScaleUpQueueWait)
+	}
+	if opts.WorkerIdleTimeout <= 0 {
+		return fmt.Errorf("%w: worker idle timeout must be positive: %s", ErrInvalidConfiguration, opts.WorkerIdleTimeout)
+	}
+	if opts.WorkerScaleInterval <= 0 {
+		return fmt.Errorf("%w: worker scale interval must be positive: %s", ErrInvalidConfiguration, opts.WorkerScaleInterval)
+	}
+	return nil
+}
+
+func newWorkerAutoscale(opts ServerOptions) workerAutoscale {
+	if opts.MaxWorkerCount == 0 {
+		return workerAutoscale{}
+	}
+	return workerAutoscale{
+		minWorkers:      int(opts.MinWorkerCount),
+		queuedThreshold: opts.WorkerScaleUpQueuedTasks,
+		waitThreshold:   opts.WorkerScaleUpQueueWait,
+		idleTimeout:     opts.WorkerIdleTimeout,
+		interval:        opts.WorkerScaleInterval,
+	}
+}
+
+func (a workerAutoscale) enabled() bool {
+	return a.minWorkers > 0
+}
+
+func (p *workerPool) runAutoscaler() {
+	defer p.runnersWG.Done()
+
+	ticker := time.NewTicker(p.autoscale.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.drain:
+			return
+		case now := <-ticker.C:
+			if p.overloaded(now) {
+				p.scaleUp()
+			}
+		}
+	}
+}
+
+func (p *workerPool) overloaded(now time.Time) bool {
+	var (
+		queued uint64
+		oldest time.Time
+	)
+	for _, worker := range p.workers {
+		count, enqueued := worker.queued()
+		queued += uint64(count)
+		if !enqueued.IsZero() && (oldest.IsZero() || enqueued.Before(oldest)) {
+			oldest = enqueued
+		}
+	}
+	if p.autoscale.queuedThreshold > 0 && queued >= p.autoscale.queuedThreshold {
+		return true
+	}
+	return p.autoscale.waitThreshold > 0 && !oldest.IsZero() && now.Sub(oldest) >= p.autoscale.waitThreshold
+}
+
+func (p *workerPool) scaleUp() {
+	p.runnersMu.Lock()
+	defer p.runnersMu.Unlock()
+
+	for _, worker := range p.workers {
+		if !worker.active.Load() {
+			p.startRunnerLocked(worker)
+			debug("Worker pool grew to %d workers", p.runners)
+			return
+		}
+	}
+}
+
+func (p *workerPool) retire(w *worker) bool {
+	p.runnersMu.Lock()
+	defer p.runnersMu.Unlock()
+
+	if p.runners <= p.autoscale.minWorkers {
+		return false
+	}
+	w.idle.Store(false)
+	w.active.Store(false)
+	if p.hasQueuedTasks() {
+		w.active.Store(true)
+		return false
+	}
+	p.runners--
+	p.workersChanged()
+	return true
+}
+
+func (p *workerPool) hasQueuedTasks() bool {
+	for _, worker := range p.workers {
+		if count, _ := worker.queued(); count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *workerPool) startRunnerLocked(w *worker) {
+	if p.runnersClosed {
+		return
+	}
+	w.active.Store(true)
+	p.runners++
+	p.runnersWG.Add(1)
+	p.workersChanged()
+	go w.serve()
+	debug("Worker %d started", w.id)
+}
+
+func (p *workerPool) runnerStopped(w *worker) {
+	p.runnersMu.Lock()
+	if w.active.Load() {
+		w.active.Store(false)
+		p.runners--
+		p.workersChanged()
+	}
+	p.runnersMu.Unlock()
+	p.runnersWG.Done()
+}
+
+func (p *workerPool) workersChanged() {
+	if p.metrics != nil {
+		p.metrics.workers.Store(uint64(p.runners))
+	}
+}
@@ -0,0 +1,105 @@
+package ramix
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newAutoscalingTestPool(metrics *serverMetrics, minWorkers, maxWorkers uint32) *workerPool {
+	pool := newWorkerPool(maxWorkers, 4)
+	pool.metrics = metrics
+	pool.autoscale = workerAutoscale{
+		minWorkers:      int(minWorkers),
+		queuedThreshold: 1,
+		idleTimeout:     50 * time.Millisecond,
+		interval:        5 * time.Millisecond,
+	}
+	return pool
+}
+
+func waitForWorkers(t *testing.T, metrics *serverMetrics, want uint64) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for metrics.snapshot().Workers != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("workers = %d, want %d", metrics.snapshot().Workers, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWorkerPoolAutoscalingGrowsAndShrinks(t *testing.T) {
+	var metrics serverMetrics
+	pool := newAutoscalingTestPool(&metrics, 1, 3)
+	pool.start()
+	waitForWorkers(t, &metrics, 1)
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newContext(context.Background(), &testConnection{id: 0}, nil)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	ran := make(chan struct{})
+	second := newContext(context.Background(), &testConnection{id: 1}, nil)
+	second.handlers = []Handler{func(*Context) { close(ran) }}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+	if err := pool.submit(second); err != nil {
+		t.Fatalf("submit(second) error = %v", err)
+	}
+	waitForSignal(t, ran, "task run by an added worker")
+	waitForWorkers(t, &metrics, 2)
+
+	close(block)
+	waitForWorkers(t, &metrics, 1)
+
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+	if got := metrics.snapshot().Workers; got != 0 {
+		t.Fatalf("workers after drain = %d, want 0", got)
+	}
+}
+
+func TestWorkerPoolAutoscalingKeepsConnectionOrder(t *testing.T) {
+	var metrics serverMetrics
+	pool := newAutoscalingTestPool(&metrics, 1, 4)
+	pool.start()
+
+	const tasks = 200
+	got := make(chan int, tasks)
+	for i := 0; i < tasks; i++ {
+		value := i
+		task := newContext(context.Background(), &testConnection{id: 2}, nil)
+		task.backpressure = Backpressure{Policy: BackpressureBlock, Timeout: time.Second}
+		task.handlers = []Handler{func(*Context) {
+			time.Sleep(50 * time.Microsecond)
+			got <- value
+		}}
+		if err := pool.submit(task); err != nil {
+			t.Fatalf("submit(%d) error = %v", value, err)
+		}
+	}
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+
+	close(got)
+	want := 0
+	for value := range got {
+		if value != want {
+			t.Fatalf("task %d ran at position %d", value, want)
+		}
+		want++
+	}
+	if want != tasks {
+		t.Fatalf("ran %d tasks, want %d", want, tasks)
+	}
+}
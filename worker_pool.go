@@ -3,6 +3,7 @@ package ramix
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 type workerPoolState uint8
@@ -15,15 +16,28 @@ const (
 )
 
 type workerPool struct {
-	workers   []*worker
-	submitMu  sync.RWMutex
-	accepting bool
-	state     workerPoolState
-	tasksMu   sync.Mutex
-	tasks     map[*Context]struct{}
-	drainOnce sync.Once
-	done      chan struct{}
-	doneOnce  sync.Once
+	workers      []*worker
+	scheduler    Scheduler
+	submitMu     sync.RWMutex
+	accepting    bool
+	state        workerPoolState
+	tasksMu      sync.Mutex
+	tasks        map[*Context]struct{}
+	drainOnce    sync.Once
+	done         chan struct{}
+	doneOnce     sync.Once
+	stopping     chan struct{}
+	stoppingOnce sync.Once
+	drain        chan struct{}
+
+	metrics         *serverMetrics
+	autoscale       workerAutoscale
+	starvationLimit int
+
+	runnersMu     sync.Mutex
+	runners       int
+	runnersClosed bool
+	runnersWG     sync.WaitGroup
 }
 
 func newWorkerPool(count, capacity uint32) *workerPool {
@@ -35,9 +49,11 @@ func newWorkerPool(count, capacity uint32) *workerPool {
 	}
 
 	pool := &workerPool{
-		workers: make([]*worker, count),
-		tasks:   make(map[*Context]struct{}),
-		done:    make(chan struct{}),
+		workers:  make([]*worker, count),
+		tasks:    make(map[*Context]struct{}),
+		done:     make(chan struct{}),
+		stopping: make(chan struct{}),
+		drain:    make(chan struct{}),
 	}
 
 	for i := range pool.workers {
@@ -58,13 +74,21 @@ func (p *workerPool) start() {
 	p.accepting = true
 	p.submitMu.Unlock()
 
-	for _, worker := range p.workers {
-		worker.start()
+	count := len(p.workers)
+	if p.autoscale.enabled() {
+		count = p.autoscale.minWorkers
+		p.runnersWG.Add(1)
+		go p.runAutoscaler()
 	}
+	p.runnersMu.Lock()
+	for _, worker := range p.workers[:count] {
+		p.startRunnerLocked(worker)
+	}
+	p.runnersMu.Unlock()
 }
 
 func (p *workerPool) submit(task *Context) error {
-	selectedWorker := p.workers[p.workerIndex(task.Connection)]
+	selectedWorker := p.selectWorker(task)
 
 	p.submitMu.RLock()
 	defer p.submitMu.RUnlock()
@@ -76,23 +100,125 @@ func (p *workerPool) submit(task *Context) error {
 	p.register(task)
 	task.taskQueued()
 
-	select {
-	case selectedWorker.tasks <- task:
+	if p.push(selectedWorker, task) {
 		return nil
+	}
+
+	switch task.backpressure.Policy {
+	case BackpressureBlock:
+		return p.submitBlocking(selectedWorker, task, task.backpressure.Timeout)
+	case BackpressureDropOldest:
+		return p.submitEvicting(selectedWorker, task)
 	default:
-		task.taskDequeued()
-		task.taskRejected()
-		p.unregister(task)
+		p.reject(task)
 		return ErrWorkerQueueFull
 	}
 }
 
+func (p *workerPool) push(selectedWorker *worker, task *Context) bool {
+	if !selectedWorker.push(task) {
+		return false
+	}
+	p.wake(selectedWorker)
+	return true
+}
+
+func (p *workerPool) requeue(selectedWorker *worker, task *Context) {
+	selectedWorker.pushLimited(task, false)
+	p.wake(selectedWorker)
+}
+
+func (p *workerPool) wake(selectedWorker *worker) {
+	if selectedWorker.active.Load() {
+		signal(selectedWorker.wake)
+		if selectedWorker.idle.Load() {
+			return
+		}
+	}
+	p.wakeIdle(selectedWorker)
+}
+
+func (p *workerPool) submitBlocking(selectedWorker *worker, task *Context, timeout time.Duration) error {
+	task.taskBlocked()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-selectedWorker.space:
+			if p.push(selectedWorker, task) {
+				signal(selectedWorker.space)
+				return nil
+			}
+		case <-timer.C:
+			p.reject(task)
+			return ErrWorkerQueueFull
+		case <-p.stopping:
+			p.reject(task)
+			return ErrServerStopping
+		case <-task.Done():
+			p.reject(task)
+			return ErrConnectionClosed
+		}
+	}
+}
+
+func (p *workerPool) submitEvicting(selectedWorker *worker, task *Context) error {
+	for !p.push(selectedWorker, task) {
+		oldest := selectedWorker.evictOldest()
+		if oldest == nil {
+			p.reject(task)
+			return ErrWorkerQueueFull
+		}
+		oldest.taskDequeued()
+		oldest.taskEvicted()
+		p.unregister(oldest)
+		oldest.finish()
+	}
+	return nil
+}
+
+func (p *workerPool) take(home *worker) (*Context, *worker) {
+	if task := home.take(); task != nil {
+		return task, home
+	}
+	for offset := 1; offset < len(p.workers); offset++ {
+		other := p.workers[(home.id+offset)%len(p.workers)]
+		if task := other.takeOrdered(); task != nil {
+			return task, other
+		}
+	}
+	for offset := 1; offset < len(p.workers); offset++ {
+		victim := p.workers[(home.id+offset)%len(p.workers)]
+		if task := victim.steal(); task != nil {
+			return task, victim
+		}
+	}
+	return nil, nil
+}
+
+func (p *workerPool) wakeIdle(except *worker) {
+	for _, candidate := range p.workers {
+		if candidate != except && candidate.active.Load() && candidate.idle.Load() {
+			signal(candidate.wake)
+			return
+		}
+	}
+}
+
+func (p *workerPool) reject(task *Context) {
+	task.taskDequeued()
+	task.taskRejected()
+	p.unregister(task)
+}
+
 func (p *workerPool) stopAcceptingAndDrain(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
 	p.drainOnce.Do(func() {
+		p.signalStopping()
 		p.submitMu.Lock()
 		p.accepting = false
 
@@ -109,15 +235,15 @@ func (p *workerPool) stopAcceptingAndDrain(ctx context.Context) error {
 			return
 		}
 
-		for _, worker := range p.workers {
-			close(worker.tasks)
-		}
+		close(p.drain)
 		p.submitMu.Unlock()
 
+		p.runnersMu.Lock()
+		p.runnersClosed = true
+		p.runnersMu.Unlock()
+
 		go func() {
-			for _, worker := range p.workers {
-				<-worker.done
-			}
+			p.runnersWG.Wait()
 
 			p.submitMu.Lock()
 			p.state = workerPoolStateStopped
@@ -137,6 +263,7 @@ func (p *workerPool) stopAcceptingAndDrain(ctx context.Context) error {
 }
 
 func (p *workerPool) stopAccepting() {
+	p.signalStopping()
 	p.submitMu.Lock()
 	p.accepting = false
 	p.submitMu.Unlock()
@@ -148,12 +275,43 @@ func (p *workerPool) forceCancel() {
 	}
 }
 
+func (p *workerPool) signalStopping() {
+	p.stoppingOnce.Do(func() {
+		close(p.stopping)
+	})
+}
+
 func (p *workerPool) closeDone() {
 	p.doneOnce.Do(func() {
 		close(p.done)
 	})
 }
 
+func (p *workerPool) selectWorker(task *Context) *worker {
+	var index int
+	switch {
+	case task.orderIndependent:
+		index = leastLoadedWorker(task, p)
+	case p.scheduler != nil:
+		index = p.scheduler.Schedule(task, p)
+	default:
+		return p.workers[p.workerIndex(task.Connection)]
+	}
+	index %= len(p.workers)
+	if index < 0 {
+		index += len(p.workers)
+	}
+	return p.workers[index]
+}
+
+func (p *workerPool) Len() int {
+	return len(p.workers)
+}
+
+func (p *workerPool) Load(i int) int {
+	return p.workers[i].load()
+}
+
 func (p *workerPool) workerIndex(connection Connection) uint64 {
 	if connection == nil {
 		return 0
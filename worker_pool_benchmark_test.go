@@ -0,0 +1,127 @@
+package ramix
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// channelWorkerPool is the previous worker pool design, kept as a benchmark
+// baseline: every worker owns a buffered channel, and tasks are assigned by
+// connection ID with no way to move to an idle worker.
+type channelWorkerPool struct {
+	queues []chan *Context
+	wg     sync.WaitGroup
+}
+
+func newChannelWorkerPool(count, capacity int) *channelWorkerPool {
+	pool := &channelWorkerPool{queues: make([]chan *Context, count)}
+	for i := range pool.queues {
+		queue := make(chan *Context, capacity)
+		pool.queues[i] = queue
+		pool.wg.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			for task := range queue {
+				task.Next()
+				task.finish()
+			}
+		}()
+	}
+	return pool
+}
+
+func (p *channelWorkerPool) submit(task *Context) error {
+	select {
+	case p.queues[task.Connection.ID()%uint64(len(p.queues))] <- task:
+		return nil
+	default:
+		return ErrWorkerQueueFull
+	}
+}
+
+func (p *channelWorkerPool) stop() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.wg.Wait()
+}
+
+type workerPoolBenchmark struct {
+	connections      int
+	orderIndependent bool
+}
+
+func BenchmarkWorkerPool(b *testing.B) {
+	SetMode(ReleaseMode)
+	defer SetMode(DebugMode)
+
+	const workers = 4
+	for _, scenario := range []struct {
+		name string
+		workerPoolBenchmark
+	}{
+		{name: "ManyConnectionsOrdered", workerPoolBenchmark: workerPoolBenchmark{connections: 64 * workers}},
+		{name: "HotConnectionOrderIndependent", workerPoolBenchmark: workerPoolBenchmark{connections: 1, orderIndependent: true}},
+	} {
+		scenario := scenario
+		b.Run(scenario.name+"/Channel", func(b *testing.B) {
+			pool := newChannelWorkerPool(workers, 64)
+			scenario.run(b, pool.submit)
+			pool.stop()
+		})
+		b.Run(scenario.name+"/WorkStealing", func(b *testing.B) {
+			pool := newWorkerPool(uint32(workers), 64)
+			pool.start()
+			scenario.run(b, pool.submit)
+			if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+				b.Fatalf("stopAcceptingAndDrain() error = %v", err)
+			}
+		})
+	}
+}
+
+// run submits b.N small tasks, retrying rejected submissions, and reports how
+// often a worker queue was full.
+func (s workerPoolBenchmark) run(b *testing.B, submit func(*Context) error) {
+	connections := make([]*testConnection, s.connections)
+	for i := range connections {
+		connections[i] = &testConnection{id: uint64(i)}
+	}
+	var (
+		completed sync.WaitGroup
+		rejected  atomic.Uint64
+	)
+	handler := func(*Context) {
+		spinBenchmarkWork()
+		completed.Done()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	completed.Add(b.N)
+	for i := 0; i < b.N; i++ {
+		task := newContext(context.Background(), connections[i%len(connections)], nil)
+		task.handlers = []Handler{handler}
+		task.orderIndependent = s.orderIndependent
+		for submit(task) != nil {
+			rejected.Add(1)
+			runtime.Gosched()
+		}
+	}
+	completed.Wait()
+	b.StopTimer()
+	b.ReportMetric(float64(rejected.Load())/float64(b.N), "rejects/op")
+}
+
+var benchmarkWorkSink atomic.Uint64
+
+func spinBenchmarkWork() {
+	var sum uint64
+	for i := uint64(0); i < 2000; i++ {
+		sum += i * i
+	}
+	benchmarkWorkSink.Add(sum)
+}
@@ -749,9 +749,306 @@ func assertNotClosed(t *testing.T, ch <-chan struct{}, label string) {
 	}
 }
 
+func waitForIdleWorker(t *testing.T, pool *workerPool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		for _, w := range pool.workers {
+			if w.idle.Load() {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no worker went idle")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func waitForMetrics(t *testing.T, metrics *serverMetrics, condition func(ServerStats) bool, label string) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats := metrics.snapshot()
+		if condition(stats) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s; final snapshot = %+v", label, stats)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 func newMetricsContext(metrics *serverMetrics, transport Transport, connectionID uint64) *Context {
 	task := newContext(context.Background(), &testConnection{id: connectionID}, nil)
 	task.metrics = metrics
 	task.metricTransport = transport
 	return task
 }
+
+func TestWorkerPoolBackpressureBlockWaitsForQueueSpace(t *testing.T) {
+	pool := newWorkerPool(1, 1)
+	pool.start()
+
+	var metrics serverMetrics
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newMetricsContext(&metrics, TransportTCP, 1)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	second := newMetricsContext(&metrics, TransportTCP, 1)
+	second.handlers = []Handler{func(*Context) {}}
+	third := newMetricsContext(&metrics, TransportTCP, 1)
+	third.backpressure = Backpressure{Policy: BackpressureBlock, Timeout: time.Minute}
+	ran := make(chan struct{})
+	third.handlers = []Handler{func(*Context) { close(ran) }}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+	if err := pool.submit(second); err != nil {
+		t.Fatalf("submit(second) error = %v", err)
+	}
+
+	submitted := make(chan error, 1)
+	go func() { submitted <- pool.submit(third) }()
+	select {
+	case err := <-submitted:
+		t.Fatalf("submit(third) returned %v before queue space freed", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(block)
+	if err := <-submitted; err != nil {
+		t.Fatalf("submit(third) error = %v", err)
+	}
+	waitForSignal(t, ran, "blocked task run")
+
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+	stats := metrics.snapshot().TCP
+	if stats.BlockedTasks != 1 || stats.RejectedTasks != 0 {
+		t.Fatalf("blocked, rejected tasks = %d, %d, want 1, 0", stats.BlockedTasks, stats.RejectedTasks)
+	}
+}
+
+func TestWorkerPoolBackpressureBlockTimesOut(t *testing.T) {
+	pool := newWorkerPool(1, 1)
+	pool.start()
+
+	var metrics serverMetrics
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newMetricsContext(&metrics, TransportTCP, 1)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	second := newMetricsContext(&metrics, TransportTCP, 1)
+	second.handlers = []Handler{func(*Context) {}}
+	third := newMetricsContext(&metrics, TransportTCP, 1)
+	third.backpressure = Backpressure{Policy: BackpressureBlock, Timeout: 10 * time.Millisecond}
+	third.handlers = []Handler{func(*Context) {}}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+	if err := pool.submit(second); err != nil {
+		t.Fatalf("submit(second) error = %v", err)
+	}
+	if err := pool.submit(third); !errors.Is(err, ErrWorkerQueueFull) {
+		t.Fatalf("submit(third) error = %v, want %v", err, ErrWorkerQueueFull)
+	}
+	if got := metrics.snapshot().TCP.RejectedTasks; got != 1 {
+		t.Fatalf("rejected tasks after block timeout = %d, want 1", got)
+	}
+
+	close(block)
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+}
+
+func TestWorkerPoolBackpressureBlockReleasesOnStop(t *testing.T) {
+	pool := newWorkerPool(1, 1)
+	pool.start()
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newContext(context.Background(), &testConnection{id: 1}, nil)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	second := newContext(context.Background(), &testConnection{id: 1}, nil)
+	second.handlers = []Handler{func(*Context) {}}
+	var metrics serverMetrics
+	third := newMetricsContext(&metrics, TransportTCP, 1)
+	third.backpressure = Backpressure{Policy: BackpressureBlock, Timeout: time.Minute}
+	third.handlers = []Handler{func(*Context) {}}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+	if err := pool.submit(second); err != nil {
+		t.Fatalf("submit(second) error = %v", err)
+	}
+	submitted := make(chan error, 1)
+	go func() { submitted <- pool.submit(third) }()
+	waitForMetrics(t, &metrics, func(stats ServerStats) bool { return stats.TCP.BlockedTasks == 1 }, "blocked submit")
+
+	drained := make(chan error, 1)
+	go func() { drained <- pool.stopAcceptingAndDrain(context.Background()) }()
+	select {
+	case err := <-submitted:
+		if !errors.Is(err, ErrServerStopping) {
+			t.Fatalf("submit(third) error = %v, want %v", err, ErrServerStopping)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked submit was not released by stop")
+	}
+	close(block)
+	if err := <-drained; err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+}
+
+func TestWorkerPoolBackpressureDropOldestEvictsQueuedTask(t *testing.T) {
+	pool := newWorkerPool(1, 1)
+	pool.start()
+
+	var metrics serverMetrics
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newMetricsContext(&metrics, TransportTCP, 1)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	second := newMetricsContext(&metrics, TransportTCP, 1)
+	second.handlers = []Handler{func(*Context) {
+		t.Error("evicted task handler should not run")
+	}}
+	third := newMetricsContext(&metrics, TransportTCP, 1)
+	third.backpressure = Backpressure{Policy: BackpressureDropOldest}
+	ran := make(chan struct{})
+	third.handlers = []Handler{func(*Context) { close(ran) }}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+	if err := pool.submit(second); err != nil {
+		t.Fatalf("submit(second) error = %v", err)
+	}
+	if err := pool.submit(third); err != nil {
+		t.Fatalf("submit(third) error = %v", err)
+	}
+	if second.Err() == nil {
+		t.Fatal("evicted task context was not finished")
+	}
+	close(block)
+	waitForSignal(t, ran, "newest task run")
+
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+	stats := metrics.snapshot().TCP
+	if stats.EvictedTasks != 1 || stats.QueuedTasks != 0 {
+		t.Fatalf("evicted, queued tasks = %d, %d, want 1, 0", stats.EvictedTasks, stats.QueuedTasks)
+	}
+}
+
+func TestWorkerPoolIdleWorkerStealsOrderIndependentTask(t *testing.T) {
+	pool := newWorkerPool(2, 1)
+	pool.start()
+
+	blockFirst := make(chan struct{})
+	blockSecond := make(chan struct{})
+	firstStarted := make(chan struct{})
+	secondStarted := make(chan struct{})
+	first := newContext(context.Background(), &testConnection{id: 0}, nil)
+	first.handlers = []Handler{func(*Context) {
+		close(firstStarted)
+		<-blockFirst
+	}}
+	second := newContext(context.Background(), &testConnection{id: 1}, nil)
+	second.handlers = []Handler{func(*Context) {
+		close(secondStarted)
+		<-blockSecond
+	}}
+	shared := newContext(context.Background(), &testConnection{id: 0}, nil)
+	shared.orderIndependent = true
+	ran := make(chan struct{})
+	shared.handlers = []Handler{func(*Context) { close(ran) }}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	if err := pool.submit(second); err != nil {
+		t.Fatalf("submit(second) error = %v", err)
+	}
+	waitForSignal(t, firstStarted, "first task start")
+	waitForSignal(t, secondStarted, "second task start")
+	if err := pool.submit(shared); err != nil {
+		t.Fatalf("submit(shared) error = %v", err)
+	}
+	if got := pool.workers[0].load(); got != 2 {
+		t.Fatalf("worker 0 load = %d, want 2", got)
+	}
+
+	close(blockSecond)
+	waitForSignal(t, ran, "stolen task run")
+
+	close(blockFirst)
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+}
+
+func TestWorkerPoolDoesNotStealOrderedTasks(t *testing.T) {
+	pool := newWorkerPool(2, 2)
+	pool.start()
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	first := newContext(context.Background(), &testConnection{id: 0}, nil)
+	first.handlers = []Handler{func(*Context) {
+		close(started)
+		<-block
+	}}
+	ran := make(chan struct{})
+	second := newContext(context.Background(), &testConnection{id: 0}, nil)
+	second.handlers = []Handler{func(*Context) { close(ran) }}
+
+	if err := pool.submit(first); err != nil {
+		t.Fatalf("submit(first) error = %v", err)
+	}
+	waitForSignal(t, started, "first task start")
+	if err := pool.submit(second); err != nil {
+		t.Fatalf("submit(second) error = %v", err)
+	}
+	markerRan := make(chan struct{})
+	marker := newContext(context.Background(), &testConnection{id: 1}, nil)
+	marker.handlers = []Handler{func(*Context) { close(markerRan) }}
+	if err := pool.submit(marker); err != nil {
+		t.Fatalf("submit(marker) error = %v", err)
+	}
+	waitForSignal(t, markerRan, "task on the free worker")
+	waitForIdleWorker(t, pool)
+	assertNotClosed(t, ran, "ordered task behind a running task")
+
+	close(block)
+	waitForSignal(t, ran, "ordered task run")
+	if err := pool.stopAcceptingAndDrain(context.Background()); err != nil {
+		t.Fatalf("stopAcceptingAndDrain() error = %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -13,7 +14,9 @@ const webSocketControlWriteTimeout = 5 * time.Second
 
 type WebSocketConnection struct {
 	*netConnection
-	socket *websocket.Conn
+	socket       *websocket.Conn
+	wireBytes    *atomic.Uint64
+	textMessages bool
 }
 
 func (c *WebSocketConnection) open() {
@@ -21,6 +24,10 @@ func (c *WebSocketConnection) open() {
 	c.start(c, c.reader)
 }
 
+func (c *WebSocketConnection) Subprotocol() string {
+	return c.subprotocol
+}
+
 func (c *WebSocketConnection) installControlHandlers() {
 	c.socket.SetPingHandler(func(applicationData string) error {
 		c.refreshActivity()
@@ -34,10 +41,19 @@ func (c *WebSocketConnection) installControlHandlers() {
 		}
 		return err
 	})
-	c.socket.SetPongHandler(func(string) error {
-		c.refreshActivity()
+	c.socket.SetPongHandler(func(applicationData string) error {
+		c.receivePong([]byte(applicationData))
 		return nil
 	})
+	if c.server.WebSocketPing {
+		c.ping = func(payload []byte) error {
+			return c.socket.WriteControl(
+				websocket.PingMessage,
+				payload,
+				time.Now().Add(webSocketControlWriteTimeout),
+			)
+		}
+	}
 }
 
 func (c *WebSocketConnection) reader() {
@@ -61,6 +77,12 @@ func (c *WebSocketConnection) reader() {
 			return
 		}
 
+		if messageType == websocket.TextMessage && c.textMessages {
+			if !c.handleTextMessage(buffer) {
+				return
+			}
+			continue
+		}
 		if messageType != websocket.BinaryMessage {
 			c.fail(OperationProtocol, fmt.Errorf(
 				"%w: websocket message type %d is not binary",
@@ -97,23 +119,44 @@ func (c *WebSocketConnection) reader() {
 				c.fail(OperationProtocol, err)
 				return
 			}
-			c.server.metrics.messageReceived(c.metricTransport, uint64(len(message.Body)))
-
-			err = c.server.handleRequest(c, newRequest(message))
-			switch {
-			case err == nil:
-			case errors.Is(err, ErrServerStopping):
-				return
-			default:
-				c.fail(OperationTask, err)
+			if !c.dispatch(message) {
 				return
 			}
 		}
 	}
 }
 
-func (c *WebSocketConnection) fail(operation ConnectionOperation, err error) {
-	if c.tryRequestClose(operation, err) {
-		c.server.reportConnectionError(c, operation, err)
+func (c *WebSocketConnection) handleTextMessage(buffer []byte) bool {
+	if uint64(len(buffer)) > c.server.MaxFrameLength {
+		c.fail(OperationProtocol, fmt.Errorf(
+			"%w: websocket text message length %d exceeds max frame length %d",
+			ErrFrameTooLarge,
+			len(buffer),
+			c.server.MaxFrameLength,
+		))
+		return false
+	}
+
+	c.refreshActivity()
+	message, err := decodeTextMessage(buffer)
+	if err != nil {
+		c.fail(OperationProtocol, err)
+		return false
+	}
+	return c.dispatch(message)
+}
+
+func (c *WebSocketConnection) dispatch(message Message) bool {
+	c.server.metrics.messageReceived(c.metricTransport, uint64(len(message.Body)))
+
+	err := c.server.handleRequest(c, newRequest(message))
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrServerStopping), errors.Is(err, ErrConnectionClosed):
+		return false
+	default:
+		c.fail(OperationTask, err)
+		return false
 	}
 }